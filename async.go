@@ -0,0 +1,305 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// AsyncHandler writes records asynchronously, records are queued into
+// the bounded queue and written by background routine. Flush or Close have
+// to be called before the process exits (or Lambda is frozen).
+type AsyncHandler struct {
+	slog.Handler
+	queue *asyncQueue
+}
+
+// The overflow policy of async handler
+type overflow int
+
+const (
+	overflowBlock overflow = iota
+	overflowDropNewest
+	overflowDropOldest
+	overflowDropBelow
+)
+
+// The config option of async handler
+type AsyncOption func(*asyncOpts)
+
+type asyncOpts struct {
+	queueSize int
+	overflow  overflow
+	dropBelow slog.Level
+}
+
+// Async logger handler, it wraps the handler so that slow writer does not
+// stall the logging routines.
+//
+//	h := logger.NewAsyncHandler(logger.NewJSONHandler(), logger.WithAsyncDropOldest())
+//	defer h.Close()
+//
+// The queue of 1024 records is used by default, the logging routine is blocked
+// if queue is full unless other overflow policy is configured. CRITICAL and
+// EMERGENCY records are never dropped, they wait for the space in the queue.
+// Records logged after Close are written synchronously.
+func NewAsyncHandler(h slog.Handler, opts ...AsyncOption) *AsyncHandler {
+	config := &asyncOpts{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	if config.queueSize <= 0 {
+		config.queueSize = 1024
+	}
+
+	q := &asyncQueue{
+		ring:     make([]asyncEntry, config.queueSize),
+		overflow: config.overflow,
+		level:    config.dropBelow,
+		done:     make(chan struct{}),
+	}
+	q.notEmpty = sync.NewCond(&q.mu)
+	q.notFull = sync.NewCond(&q.mu)
+	q.idle = sync.NewCond(&q.mu)
+	go q.run()
+
+	return &AsyncHandler{
+		Handler: &asyncHandler{Handler: h, queue: q},
+		queue:   q,
+	}
+}
+
+// Flush waits until queued records are written
+func (h *AsyncHandler) Flush(ctx context.Context) error {
+	return h.queue.flush(ctx)
+}
+
+// Close writes queued records and stops background routine
+func (h *AsyncHandler) Close() error {
+	return h.queue.close()
+}
+
+// Dropped returns number of records dropped due to queue overflow
+func (h *AsyncHandler) Dropped() uint64 {
+	return h.queue.dropped.Load()
+}
+
+// Config size of async handler queue, default 1024 records
+func WithAsyncQueue(size int) AsyncOption {
+	return func(o *asyncOpts) {
+		o.queueSize = size
+	}
+}
+
+// Block logging routine if async handler queue is full, it is default policy
+func WithAsyncBlock() AsyncOption {
+	return func(o *asyncOpts) {
+		o.overflow = overflowBlock
+	}
+}
+
+// Drop new records if async handler queue is full
+func WithAsyncDropNewest() AsyncOption {
+	return func(o *asyncOpts) {
+		o.overflow = overflowDropNewest
+	}
+}
+
+// Drop the oldest queued records if async handler queue is full
+func WithAsyncDropOldest() AsyncOption {
+	return func(o *asyncOpts) {
+		o.overflow = overflowDropOldest
+	}
+}
+
+// Drop records below the level if async handler queue is full, records at
+// the level and above block logging routine.
+func WithAsyncDropBelow(level slog.Level) AsyncOption {
+	return func(o *asyncOpts) {
+		o.overflow = overflowDropBelow
+		o.dropBelow = level
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type asyncHandler struct {
+	slog.Handler
+	queue *asyncQueue
+}
+
+func (h *asyncHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &asyncHandler{Handler: h.Handler.WithAttrs(attrs), queue: h.queue}
+}
+
+func (h *asyncHandler) WithGroup(name string) slog.Handler {
+	return &asyncHandler{Handler: h.Handler.WithGroup(name), queue: h.queue}
+}
+
+func (h *asyncHandler) Handle(ctx context.Context, r slog.Record) error {
+	if !h.queue.enqueue(asyncEntry{ctx: ctx, r: r.Clone(), h: h.Handler}) {
+		return h.Handler.Handle(ctx, r)
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+type asyncEntry struct {
+	ctx context.Context
+	r   slog.Record
+	h   slog.Handler
+}
+
+// asyncQueue is bounded ring of records written by background routine
+type asyncQueue struct {
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	idle     *sync.Cond
+	ring     []asyncEntry
+	head     int
+	size     int
+	busy     bool
+	closed   bool
+	done     chan struct{}
+
+	overflow overflow
+	level    slog.Level
+	dropped  atomic.Uint64
+}
+
+// enqueue returns false if the queue is closed
+func (q *asyncQueue) enqueue(e asyncEntry) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for !q.closed && q.size == len(q.ring) {
+		if e.r.Level >= CRITICAL {
+			q.notFull.Wait()
+			continue
+		}
+
+		switch q.overflow {
+		case overflowDropNewest:
+			q.dropped.Add(1)
+			return true
+		case overflowDropOldest:
+			if q.evict() {
+				q.dropped.Add(1)
+				continue
+			}
+		case overflowDropBelow:
+			if e.r.Level < q.level {
+				q.dropped.Add(1)
+				return true
+			}
+		}
+
+		q.notFull.Wait()
+	}
+
+	if q.closed {
+		return false
+	}
+
+	q.ring[(q.head+q.size)%len(q.ring)] = e
+	q.size++
+	q.notEmpty.Signal()
+	return true
+}
+
+// evict removes the oldest record below CRITICAL level
+func (q *asyncQueue) evict() bool {
+	for i := 0; i < q.size; i++ {
+		at := (q.head + i) % len(q.ring)
+		if q.ring[at].r.Level >= CRITICAL {
+			continue
+		}
+
+		// shift records ahead of evicted one
+		for j := i; j > 0; j-- {
+			q.ring[(q.head+j)%len(q.ring)] = q.ring[(q.head+j-1)%len(q.ring)]
+		}
+		q.ring[q.head] = asyncEntry{}
+		q.head = (q.head + 1) % len(q.ring)
+		q.size--
+		return true
+	}
+
+	return false
+}
+
+func (q *asyncQueue) run() {
+	defer close(q.done)
+
+	for {
+		q.mu.Lock()
+		for q.size == 0 && !q.closed {
+			q.notEmpty.Wait()
+		}
+
+		if q.size == 0 {
+			q.mu.Unlock()
+			return
+		}
+
+		e := q.ring[q.head]
+		q.ring[q.head] = asyncEntry{}
+		q.head = (q.head + 1) % len(q.ring)
+		q.size--
+		q.busy = true
+		q.notFull.Signal()
+		q.mu.Unlock()
+
+		// the background routine has no caller to report the error
+		_ = e.h.Handle(e.ctx, e.r)
+
+		q.mu.Lock()
+		q.busy = false
+		if q.size == 0 {
+			q.idle.Broadcast()
+		}
+		q.mu.Unlock()
+	}
+}
+
+func (q *asyncQueue) flush(ctx context.Context) error {
+	idle := make(chan struct{})
+	go func() {
+		q.mu.Lock()
+		for q.size != 0 || q.busy {
+			q.idle.Wait()
+		}
+		q.mu.Unlock()
+		close(idle)
+	}()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *asyncQueue) close() error {
+	q.mu.Lock()
+	q.closed = true
+	q.notEmpty.Broadcast()
+	q.notFull.Broadcast()
+	q.mu.Unlock()
+
+	<-q.done
+	return nil
+}
@@ -0,0 +1,252 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gateHandler records messages, it blocks until the gate is open
+type gateHandler struct {
+	slog.Handler
+	mu      sync.Mutex
+	msgs    []string
+	entered chan struct{}
+	gate    chan struct{}
+}
+
+func newGateHandler() *gateHandler {
+	return &gateHandler{
+		Handler: slog.NewJSONHandler(nil, nil),
+		entered: make(chan struct{}, 100),
+		gate:    make(chan struct{}),
+	}
+}
+
+func (h *gateHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *gateHandler) Handle(_ context.Context, r slog.Record) error {
+	h.entered <- struct{}{}
+	<-h.gate
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.msgs = append(h.msgs, r.Message)
+	return nil
+}
+
+func (h *gateHandler) messages() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return strings.Join(h.msgs, ",")
+}
+
+// testOverflow logs "a" (blocked by the writer) and overflows the queue of
+// 2 records, the gate is opened after records logged by f.
+func testOverflow(t *testing.T, f func(log *slog.Logger), blocked func(log *slog.Logger), opts ...AsyncOption) (*AsyncHandler, *gateHandler) {
+	t.Helper()
+
+	gate := newGateHandler()
+	h := NewAsyncHandler(gate, append([]AsyncOption{WithAsyncQueue(2)}, opts...)...)
+	log := slog.New(h)
+
+	log.Info("a")
+	<-gate.entered
+
+	f(log)
+
+	done := make(chan struct{})
+	if blocked != nil {
+		go func() {
+			blocked(log)
+			close(done)
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+		select {
+		case <-done:
+			t.Errorf("logging routine is not blocked")
+		default:
+		}
+	} else {
+		close(done)
+	}
+
+	close(gate.gate)
+	<-done
+
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return h, gate
+}
+
+func TestAsyncHandler(t *testing.T) {
+	t.Run("Flush", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		h := NewAsyncHandler(NewJSONHandler(WithWriter(b)))
+		defer h.Close()
+
+		log := slog.New(h).With("a", 1).WithGroup("g")
+		for i := 0; i < 100; i++ {
+			log.Info("test", "i", i)
+		}
+
+		if err := h.Flush(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+		if len(lines) != 100 || !strings.Contains(lines[99], `"a":1,"g":{"i":99}`) {
+			t.Errorf("unexpected log lines %s", b.String())
+		}
+	})
+
+	t.Run("FlushTimeout", func(t *testing.T) {
+		gate := newGateHandler()
+		h := NewAsyncHandler(gate)
+		defer func() { close(gate.gate); h.Close() }()
+
+		slog.New(h).Info("a")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if err := h.Flush(ctx); err == nil {
+			t.Errorf("timeout is expected")
+		}
+	})
+
+	t.Run("Block", func(t *testing.T) {
+		h, gate := testOverflow(t,
+			func(log *slog.Logger) {
+				log.Info("b")
+				log.Info("c")
+			},
+			func(log *slog.Logger) { log.Info("d") },
+		)
+
+		if msgs := gate.messages(); msgs != "a,b,c,d" || h.Dropped() != 0 {
+			t.Errorf("unexpected messages %s, dropped %d", msgs, h.Dropped())
+		}
+	})
+
+	t.Run("DropNewest", func(t *testing.T) {
+		h, gate := testOverflow(t,
+			func(log *slog.Logger) {
+				log.Info("b")
+				log.Info("c")
+				log.Info("d")
+			},
+			nil,
+			WithAsyncDropNewest(),
+		)
+
+		if msgs := gate.messages(); msgs != "a,b,c" || h.Dropped() != 1 {
+			t.Errorf("unexpected messages %s, dropped %d", msgs, h.Dropped())
+		}
+	})
+
+	t.Run("DropOldest", func(t *testing.T) {
+		h, gate := testOverflow(t,
+			func(log *slog.Logger) {
+				log.Info("b")
+				log.Info("c")
+				log.Info("d")
+			},
+			nil,
+			WithAsyncDropOldest(),
+		)
+
+		if msgs := gate.messages(); msgs != "a,c,d" || h.Dropped() != 1 {
+			t.Errorf("unexpected messages %s, dropped %d", msgs, h.Dropped())
+		}
+	})
+
+	t.Run("DropOldestCritical", func(t *testing.T) {
+		h, gate := testOverflow(t,
+			func(log *slog.Logger) {
+				log.Log(context.Background(), CRITICAL, "b")
+				log.Info("c")
+				log.Info("d")
+			},
+			nil,
+			WithAsyncDropOldest(),
+		)
+
+		if msgs := gate.messages(); msgs != "a,b,d" || h.Dropped() != 1 {
+			t.Errorf("unexpected messages %s, dropped %d", msgs, h.Dropped())
+		}
+	})
+
+	t.Run("DropBelow", func(t *testing.T) {
+		h, gate := testOverflow(t,
+			func(log *slog.Logger) {
+				log.Info("b")
+				log.Warn("c")
+				log.Info("d")
+			},
+			func(log *slog.Logger) { log.Warn("e") },
+			WithAsyncDropBelow(WARN),
+		)
+
+		if msgs := gate.messages(); msgs != "a,b,c,e" || h.Dropped() != 1 {
+			t.Errorf("unexpected messages %s, dropped %d", msgs, h.Dropped())
+		}
+	})
+
+	t.Run("NeverDropCritical", func(t *testing.T) {
+		h, gate := testOverflow(t,
+			func(log *slog.Logger) {
+				log.Info("b")
+				log.Info("c")
+			},
+			func(log *slog.Logger) { log.Log(context.Background(), EMERGENCY, "d") },
+			WithAsyncDropNewest(),
+		)
+
+		if msgs := gate.messages(); msgs != "a,b,c,d" || h.Dropped() != 0 {
+			t.Errorf("unexpected messages %s, dropped %d", msgs, h.Dropped())
+		}
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		h := NewAsyncHandler(NewJSONHandler(WithWriter(b)))
+		log := slog.New(h)
+
+		log.Info("a")
+		h.Close()
+		log.Info("b")
+
+		if txt := b.String(); !strings.Contains(txt, `"msg":"a"`) || !strings.Contains(txt, `"msg":"b"`) {
+			t.Errorf("unexpected log lines %s", txt)
+		}
+	})
+}
+
+func BenchmarkAsyncHandler(b *testing.B) {
+	h := NewAsyncHandler(NewJSONHandler(WithWriter(&bytes.Buffer{})), WithAsyncDropNewest())
+	defer h.Close()
+	log := slog.New(h)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			log.Info("test", "key", "val")
+		}
+	})
+}
@@ -9,10 +9,10 @@
 package logger
 
 import (
+	"fmt"
 	"log/slog"
 	"path/filepath"
 	"strings"
-	"time"
 )
 
 // Log attributes, forammting combinator
@@ -30,9 +30,8 @@ func (attrs Attributes) handle(groups []string, a slog.Attr) slog.Attr {
 // Logs timestamp using the time format string
 func attrLogTimeFormat(format string) func([]string, slog.Attr) slog.Attr {
 	return func(groups []string, a slog.Attr) slog.Attr {
-		if a.Key == slog.TimeKey {
-			t := a.Value.Any().(time.Time)
-			return slog.String(slog.TimeKey, t.Format(format))
+		if a.Key == slog.TimeKey && a.Value.Kind() == slog.KindTime {
+			return slog.String(slog.TimeKey, a.Value.Time().Format(format))
 		}
 
 		return a
@@ -54,7 +53,10 @@ func attrNoTimestamp(groups []string, a slog.Attr) slog.Attr {
 func attrLogLevel7(color bool) func([]string, slog.Attr) slog.Attr {
 	return func(groups []string, a slog.Attr) slog.Attr {
 		if a.Key == slog.LevelKey {
-			lvl := a.Value.Any().(slog.Level)
+			lvl, ok := a.Value.Any().(slog.Level)
+			if !ok {
+				return a
+			}
 
 			name, has := levelLongName[lvl]
 			if !has {
@@ -118,12 +120,7 @@ func attrSourceShorten(groups []string, a slog.Attr) slog.Attr {
 	if a.Key == slog.SourceKey {
 		source, _ := a.Value.Any().(*slog.Source)
 		if source != nil {
-			parts := strings.Split(source.File, "go/src/")
-			path := parts[0]
-			if len(parts) > 1 {
-				path = parts[1]
-			}
-			source.File = shorten(path)
+			source.File = shorten(modPath(source.File, source.Function))
 			source.Function = shorten(source.Function)
 		}
 	}
@@ -173,3 +170,98 @@ func removeVowels(segment string) string {
 	}
 	return string(keep)
 }
+
+//------------------------------------------------------------------------------
+
+// The version of Elastic Common Schema
+const ecsVersion = "8.11.0"
+
+// Reshapes built-in attributes into Elastic Common Schema (ECS) fields
+var attrECS = Attributes{
+	attrECSTimestamp,
+	attrECSLogLevel,
+	attrECSMessage,
+	attrECSSource,
+	attrECSError,
+}
+
+// Logs timestamp as ECS @timestamp
+func attrECSTimestamp(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.TimeKey && len(groups) == 0 {
+		return slog.Attr{Key: "@timestamp", Value: a.Value}
+	}
+
+	return a
+}
+
+// Logs level as ECS log.level using lower case 7-levels names
+func attrECSLogLevel(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.LevelKey && len(groups) == 0 {
+		lvl, ok := a.Value.Any().(slog.Level)
+		if !ok {
+			return a
+		}
+
+		name, has := levelLongName[lvl]
+		if !has {
+			name = lvl.String()
+		}
+
+		return slog.String("log.level", strings.ToLower(name))
+	}
+
+	return a
+}
+
+// Logs message as ECS message
+func attrECSMessage(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.MessageKey && len(groups) == 0 {
+		return slog.Attr{Key: "message", Value: a.Value}
+	}
+
+	return a
+}
+
+// Logs source as ECS log.origin
+func attrECSSource(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.SourceKey && len(groups) == 0 {
+		source, _ := a.Value.Any().(*slog.Source)
+		if source == nil {
+			return a
+		}
+
+		return slog.Group("log.origin",
+			slog.Group("file",
+				slog.String("name", source.File),
+				slog.Int("line", source.Line),
+			),
+			slog.String("function", source.Function),
+		)
+	}
+
+	return a
+}
+
+// Logs errors (attributes err or error) as ECS error
+func attrECSError(groups []string, a slog.Attr) slog.Attr {
+	if (a.Key == "err" || a.Key == "error") && len(groups) == 0 {
+		err, ok := a.Value.Any().(error)
+		if !ok {
+			return a
+		}
+
+		attrs := []any{
+			slog.String("message", err.Error()),
+			slog.String("type", fmt.Sprintf("%T", err)),
+		}
+
+		// errors with stack trace (e.g. github.com/pkg/errors) expands it with %+v
+		if trace := fmt.Sprintf("%+v", err); trace != err.Error() {
+			attrs = append(attrs, slog.String("stack_trace", trace))
+		}
+
+		return slog.Group("error", attrs...)
+	}
+
+	return a
+}
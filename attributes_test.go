@@ -58,6 +58,16 @@ func TestAttrLogLevel7(t *testing.T) {
 	}
 }
 
+func TestAttrLogLevel7NotLevel(t *testing.T) {
+	attr := attrLogLevel7(false)
+	a := slog.String(slog.LevelKey, "custom")
+
+	result := attr([]string{"group"}, a)
+	if result.Value.String() != "custom" {
+		t.Errorf("expected custom, got %s", result.Value.String())
+	}
+}
+
 func TestAttrLogLevel7Shorten(t *testing.T) {
 	attr := attrLogLevel7Shorten(false)
 	level := slog.LevelInfo
@@ -102,6 +112,19 @@ func TestAttrSourceShorten(t *testing.T) {
 	}
 }
 
+func TestAttrSourceShortenModCache(t *testing.T) {
+	attr := attrSourceShorten
+	source := &slog.Source{File: "/root/go/pkg/mod/github.com/fogfish/logger/v3@v3.2.0/attributes.go", Function: "github.com/fogfish/logger/v3.TestFunc"}
+	a := slog.Attr{Key: slog.SourceKey, Value: slog.AnyValue(source)}
+
+	result := attr(nil, a)
+	expectedFile := "gthb.fgfs.lggr.v3/attributes.go"
+
+	if result.Value.Any().(*slog.Source).File != expectedFile {
+		t.Errorf("expected %s, got %s", expectedFile, result.Value.Any().(*slog.Source).File)
+	}
+}
+
 func TestShorten(t *testing.T) {
 	path := "github.com/fogfish/logger/attributes.go"
 	expected := "gthb.fgfs.lggr/attributes.go"
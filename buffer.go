@@ -0,0 +1,181 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+)
+
+// The number of records held by the buffer, the oldest records are dropped
+// if exceeded, the number of dropped records is reported by the first emitted
+// record as the attribute buffer_dropped
+const bufferMaxRecords = 4096
+
+// Buffer holds records logged with the context of request (tail-based
+// logging). Records are discarded at the end of successful request, records
+// of failed request (any record reached ERROR) are emitted at their original
+// levels, timestamps and source.
+type Buffer struct {
+	mu      sync.Mutex
+	ring    []bufferEntry // grows up to bufferMaxRecords, then overwritten
+	head    int           // the oldest entry of full ring
+	dropped int
+	failed  bool
+	closed  bool
+}
+
+type bufferEntry struct {
+	ctx context.Context
+	r   slog.Record
+	h   slog.Handler
+}
+
+type bufferKey struct{}
+
+// ContextWithBuffer attaches new buffer to the context, records logged with
+// the context are held until the buffer is closed at the end of request.
+// Records are held by handler created with NewBufferHandler.
+//
+//	ctx, buf := logger.ContextWithBuffer(ctx)
+//	defer buf.Close()
+//
+//	slog.InfoContext(ctx, "...")
+func ContextWithBuffer(ctx context.Context) (context.Context, *Buffer) {
+	buf := &Buffer{}
+	return context.WithValue(ctx, bufferKey{}, buf), buf
+}
+
+// BufferFromContext returns the buffer attached to the context
+func BufferFromContext(ctx context.Context) (*Buffer, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+
+	buf, ok := ctx.Value(bufferKey{}).(*Buffer)
+	return buf, ok
+}
+
+// BufferHTTP is the middleware, it attaches the buffer to the context of
+// each request. The request is failed if any record reached ERROR or
+// the handler panics.
+//
+//	http.ListenAndServe(":8080", logger.BufferHTTP(mux))
+func BufferHTTP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, buf := ContextWithBuffer(r.Context())
+
+		completed := false
+		defer func() {
+			if !completed {
+				buf.Fail()
+			}
+			buf.Close()
+		}()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+		completed = true
+	})
+}
+
+// Fail marks the request as failed, records are emitted when buffer is closed
+func (buf *Buffer) Fail() {
+	buf.mu.Lock()
+	defer buf.mu.Unlock()
+
+	buf.failed = true
+}
+
+// Close ends the request, held records are emitted if the request is failed
+// and discarded otherwise. Records logged with the context after Close are
+// not held.
+func (buf *Buffer) Close() error {
+	buf.mu.Lock()
+	ring, head, dropped, failed := buf.ring, buf.head, buf.dropped, buf.failed
+	buf.ring = nil
+	buf.closed = true
+	buf.mu.Unlock()
+
+	if !failed {
+		return nil
+	}
+
+	var errs []error
+	for i := range ring {
+		e := ring[(head+i)%len(ring)]
+		if i == 0 && dropped != 0 {
+			e.r.AddAttrs(slog.Int("buffer_dropped", dropped))
+		}
+
+		// the request context might be canceled at the end of request
+		if err := e.h.Handle(context.WithoutCancel(e.ctx), e.r); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// hold returns false if the buffer is closed
+func (buf *Buffer) hold(e bufferEntry) bool {
+	buf.mu.Lock()
+	defer buf.mu.Unlock()
+
+	if buf.closed {
+		return false
+	}
+
+	if len(buf.ring) < bufferMaxRecords {
+		buf.ring = append(buf.ring, e)
+	} else {
+		buf.ring[buf.head] = e
+		buf.head = (buf.head + 1) % len(buf.ring)
+		buf.dropped++
+	}
+
+	buf.failed = buf.failed || e.r.Level >= ERROR
+	return true
+}
+
+//------------------------------------------------------------------------------
+
+// Request buffer logger handler, it holds records logged with the context
+// having the buffer (see ContextWithBuffer). The buffer has to be the
+// outermost handler, records are held by the logging routine. Wrap the async
+// handler rather than the opposite:
+//
+//	h := logger.NewBufferHandler(logger.NewAsyncHandler(logger.NewJSONHandler()))
+func NewBufferHandler(h slog.Handler) slog.Handler {
+	return &bufferHandler{Handler: h}
+}
+
+type bufferHandler struct {
+	slog.Handler
+}
+
+func (h *bufferHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &bufferHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *bufferHandler) WithGroup(name string) slog.Handler {
+	return &bufferHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+func (h *bufferHandler) Handle(ctx context.Context, r slog.Record) error {
+	if buf, has := BufferFromContext(ctx); has {
+		if buf.hold(bufferEntry{ctx: ctx, r: r.Clone(), h: h.Handler}) {
+			return nil
+		}
+	}
+
+	return h.Handler.Handle(ctx, r)
+}
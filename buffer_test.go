@@ -0,0 +1,177 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuffer(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		log := slog.New(NewBufferHandler(NewJSONHandler(WithWriter(b))))
+
+		ctx, buf := ContextWithBuffer(context.Background())
+		log.InfoContext(ctx, "a")
+		log.WarnContext(ctx, "b")
+		log.Info("c")
+
+		if err := buf.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if txt := strings.TrimSpace(b.String()); strings.Count(txt, "\n") != 0 || !strings.Contains(txt, `"msg":"c"`) {
+			t.Errorf("unexpected log lines %s", b.String())
+		}
+	})
+
+	t.Run("Overflow", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		log := slog.New(NewBufferHandler(NewJSONHandler(WithWriter(b))))
+
+		ctx, buf := ContextWithBuffer(context.Background())
+		for i := 0; i < bufferMaxRecords+10; i++ {
+			log.InfoContext(ctx, "a", "i", i)
+		}
+		buf.Fail()
+
+		if err := buf.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+		if len(lines) != bufferMaxRecords ||
+			!strings.Contains(lines[0], `"i":10,"buffer_dropped":10`) ||
+			!strings.Contains(lines[bufferMaxRecords-1], `"i":4105`) ||
+			strings.Contains(lines[1], `buffer_dropped`) {
+			t.Errorf("unexpected log lines %s %s", lines[0], lines[bufferMaxRecords-1])
+		}
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		log := slog.New(NewBufferHandler(NewLogfmtHandler(WithWriter(b), WithTimeFormat(time.RFC3339Nano)))).With("a", 1).WithGroup("g")
+
+		ctx, buf := ContextWithBuffer(context.Background())
+		log.InfoContext(ctx, "a", "i", 1)
+		log.ErrorContext(ctx, "b", "i", 2)
+		log.Log(ctx, DEBUG, "c")
+
+		if b.Len() != 0 {
+			t.Fatalf("records are not held %s", b.String())
+		}
+
+		// the handler without buffer does not hold records
+		slog.New(NewJSONHandler(WithWriter(b))).InfoContext(ctx, "x")
+		if b.Len() == 0 {
+			t.Fatalf("records are held without buffer handler")
+		}
+		b.Reset()
+
+		time.Sleep(20 * time.Millisecond)
+		closed := time.Now()
+		if err := buf.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+		if len(lines) != 2 ||
+			!strings.Contains(lines[0], `level=INF`) ||
+			!strings.Contains(lines[0], `source=`) ||
+			!strings.Contains(lines[0], `msg=a a=1 g.i=1`) ||
+			!strings.Contains(lines[1], `level=ERR`) ||
+			!strings.Contains(lines[1], `msg=b a=1 g.i=2`) {
+			t.Fatalf("unexpected log lines %s", b.String())
+		}
+
+		ts, _, _ := strings.Cut(strings.TrimPrefix(lines[0], "time="), " ")
+		at, err := time.Parse(time.RFC3339Nano, ts)
+		if err != nil || closed.Sub(at) < 20*time.Millisecond {
+			t.Errorf("original timestamp is expected %s", lines[0])
+		}
+
+		// records are not held after close
+		b.Reset()
+		log.InfoContext(ctx, "d")
+		if !strings.Contains(b.String(), `msg=d`) {
+			t.Errorf("unexpected log lines %s", b.String())
+		}
+	})
+
+	t.Run("Fail", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		log := slog.New(NewBufferHandler(NewJSONHandler(WithWriter(b))))
+
+		ctx, buf := ContextWithBuffer(context.Background())
+		log.InfoContext(ctx, "a")
+		buf.Fail()
+		buf.Close()
+
+		if !strings.Contains(b.String(), `"msg":"a"`) {
+			t.Errorf("unexpected log lines %s", b.String())
+		}
+	})
+
+	t.Run("Async", func(t *testing.T) {
+		gate := newGateHandler()
+		h := NewAsyncHandler(gate)
+		log := slog.New(NewBufferHandler(h))
+
+		// the writer is blocked, records are held by the logging routine
+		log.Info("a")
+		<-gate.entered
+
+		ctx, buf := ContextWithBuffer(context.Background())
+		log.InfoContext(ctx, "should be discarded")
+		buf.Close()
+
+		ctx, buf = ContextWithBuffer(context.Background())
+		log.ErrorContext(ctx, "b")
+		buf.Close()
+
+		close(gate.gate)
+		if err := h.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if msgs := gate.messages(); msgs != "a,b" {
+			t.Errorf("unexpected messages %s", msgs)
+		}
+	})
+
+	t.Run("HTTP", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		log := slog.New(NewBufferHandler(NewJSONHandler(WithWriter(b))))
+
+		h := BufferHTTP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			log.InfoContext(r.Context(), r.URL.Path)
+			if r.URL.Path == "/panic" {
+				panic("failed")
+			}
+		}))
+
+		for _, path := range []string{"/ok", "/panic"} {
+			func() {
+				defer func() { recover() }()
+				h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+			}()
+		}
+
+		if txt := b.String(); strings.Contains(txt, `"msg":"/ok"`) || !strings.Contains(txt, `"msg":"/panic"`) {
+			t.Errorf("unexpected log lines %s", txt)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"log/slog"
+	"sync/atomic"
+)
+
+// pcCache is lock-free, bounded cache of module rules resolved for program
+// counters (call-sites). It is direct-mapped cache, the colliding call-sites
+// evicts each other. The cache is immutable with respect to rules, new cache
+// is required once rules are changed.
+type pcCache struct {
+	entries [pcCacheSize]atomic.Pointer[pcEntry]
+}
+
+const (
+	pcCacheBits = 10
+	pcCacheSize = 1 << pcCacheBits
+)
+
+// pcEntry is the rule resolved for the program counter
+type pcEntry struct {
+	pc    uintptr
+	level slog.Level
+	rule  bool // false if the call-site is not covered by any rule
+}
+
+func newPCCache() *pcCache {
+	return &pcCache{}
+}
+
+func (c *pcCache) index(pc uintptr) uint64 {
+	return (uint64(pc) * 0x9E3779B97F4A7C15) >> (64 - pcCacheBits)
+}
+
+func (c *pcCache) get(pc uintptr) *pcEntry {
+	if c == nil {
+		return nil
+	}
+
+	if e := c.entries[c.index(pc)].Load(); e != nil && e.pc == pc {
+		return e
+	}
+
+	return nil
+}
+
+func (c *pcCache) put(e *pcEntry) {
+	if c == nil {
+		return
+	}
+
+	c.entries[c.index(e.pc)].Store(e)
+}
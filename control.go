@@ -0,0 +1,154 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"log/slog"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fogfish/logger/v3/internal/trie"
+)
+
+// Control adjusts log levels of the running application. The global level
+// and module rules are swapped atomically, handlers observe the new
+// configuration for next record.
+//
+//	log, ctl := logger.NewWithControl()
+//	ctl.SetLevelForMod(map[string]slog.Level{
+//		"github.com/you/application": logger.DEBUG,
+//	})
+type Control struct {
+	rules   atomic.Pointer[modRules]
+	signals sync.Once
+
+	// ttl reverts of admin endpoint per target, "" is the global level
+	mu      sync.Mutex
+	reverts map[string]*time.Timer
+}
+
+// NewControl creates control of log levels, use WithControl to attach it
+// to the handler. The control is configured by options of the handler.
+func NewControl() *Control {
+	ctl := &Control{}
+	ctl.rules.Store(newModRules(INFO, nil))
+	return ctl
+}
+
+// Level returns the global log level
+func (ctl *Control) Level() slog.Level {
+	return ctl.rules.Load().level.Level()
+}
+
+// SetLevel atomically changes the global log level
+func (ctl *Control) SetLevel(level slog.Level) {
+	for {
+		rules := ctl.rules.Load()
+		if ctl.rules.CompareAndSwap(rules, rules.withLevel(level)) {
+			return
+		}
+	}
+}
+
+// LevelForMod returns copy of module rules
+func (ctl *Control) LevelForMod() map[string]slog.Level {
+	rules := ctl.rules.Load()
+	mods := make(map[string]slog.Level, len(rules.mods))
+	for mod, lvl := range rules.mods {
+		mods[mod] = lvl
+	}
+	return mods
+}
+
+// SetLevelForMod atomically replaces module rules, see WithLogLevelForMod
+// for details about rules.
+func (ctl *Control) SetLevelForMod(mods map[string]slog.Level) {
+	for {
+		rules := ctl.rules.Load()
+		if ctl.rules.CompareAndSwap(rules, newModRules(rules.level, mods)) {
+			return
+		}
+	}
+}
+
+// SetModLevel atomically sets the log level for the module
+func (ctl *Control) SetModLevel(mod string, level slog.Level) {
+	ctl.updateMods(func(mods map[string]slog.Level) { mods[mod] = level })
+}
+
+// DeleteModLevel atomically removes the module rule
+func (ctl *Control) DeleteModLevel(mod string) {
+	ctl.updateMods(func(mods map[string]slog.Level) { delete(mods, mod) })
+}
+
+func (ctl *Control) updateMods(f func(map[string]slog.Level)) {
+	for {
+		rules := ctl.rules.Load()
+		mods := make(map[string]slog.Level, len(rules.mods)+1)
+		for mod, lvl := range rules.mods {
+			mods[mod] = lvl
+		}
+		f(mods)
+
+		if ctl.rules.CompareAndSwap(rules, newModRules(rules.level, mods)) {
+			return
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// modRules is immutable snapshot of log levels configuration
+type modRules struct {
+	level    slog.Leveler          // global level
+	mods     map[string]slog.Level // module rules, "*" is the default rule
+	trie     *trie.Node
+	min      slog.Level   // minimal level across rules of the trie
+	fallback slog.Leveler // level of modules not covered by the trie
+	cache    *pcCache
+}
+
+func newModRules(level slog.Leveler, mods map[string]slog.Level) *modRules {
+	rules := &modRules{
+		level:    level,
+		mods:     make(map[string]slog.Level, len(mods)),
+		trie:     trie.New(),
+		min:      slog.Level(math.MaxInt),
+		fallback: level,
+		cache:    newPCCache(),
+	}
+
+	for mod, lvl := range mods {
+		rules.mods[mod] = lvl
+		if mod == "*" {
+			rules.fallback = lvl
+			continue
+		}
+
+		rules.trie.Append(mod, lvl)
+		if lvl < rules.min {
+			rules.min = lvl
+		}
+	}
+
+	return rules
+}
+
+// withLevel returns copy of rules with new global level, module rules and
+// cache are shared.
+func (rules *modRules) withLevel(level slog.Leveler) *modRules {
+	c := *rules
+	c.level = level
+	if _, has := rules.mods["*"]; !has {
+		c.fallback = level
+	}
+	return &c
+}
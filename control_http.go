@@ -0,0 +1,213 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ServeHTTP implements admin endpoint to inspect and change log levels.
+// Mount the control on internal admin mux:
+//
+//	mux.Handle("/admin/log", ctl)
+//
+// The endpoint supports following requests:
+//
+//	GET    /admin/log
+//	PUT    /admin/log?level=DEBUG
+//	PUT    /admin/log?mod=github.com/you/application&level=DEBUG&ttl=5m
+//	DELETE /admin/log?mod=github.com/you/application
+//
+// Levels are either long (e.g. DEBUG) or short (e.g. DEB) names. The change
+// is reverted after optional ttl unless it has been changed again.
+func (ctl *Control) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if ctl.rules.Load() == nil {
+		http.Error(w, "control is not attached to handler", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		ctl.writeLevels(w)
+	case http.MethodPut:
+		if err := ctl.httpPut(r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ctl.writeLevels(w)
+	case http.MethodDelete:
+		if err := ctl.httpDelete(r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ctl.writeLevels(w)
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+func (ctl *Control) httpPut(r *http.Request) error {
+	query := r.URL.Query()
+
+	level, err := parseLevel(query.Get("level"))
+	if err != nil {
+		return err
+	}
+
+	var ttl time.Duration
+	if v := query.Get("ttl"); v != "" {
+		ttl, err = time.ParseDuration(v)
+		if err != nil || ttl <= 0 {
+			return fmt.Errorf("invalid ttl %s", v)
+		}
+	}
+
+	ctl.mu.Lock()
+	defer ctl.mu.Unlock()
+
+	// the change cancels pending revert of the target
+	mod := query.Get("mod")
+	ctl.cancelRevert(mod)
+
+	if mod == "" {
+		prev := ctl.Level()
+		ctl.SetLevel(level)
+		if ttl > 0 {
+			ctl.scheduleRevert(mod, ttl, func() { ctl.revertLevel(level, prev) })
+		}
+		return nil
+	}
+
+	prev, has := ctl.rules.Load().mods[mod]
+	ctl.SetModLevel(mod, level)
+	if ttl > 0 {
+		ctl.scheduleRevert(mod, ttl, func() { ctl.revertModLevel(mod, level, prev, has) })
+	}
+	return nil
+}
+
+func (ctl *Control) httpDelete(r *http.Request) error {
+	mod := r.URL.Query().Get("mod")
+	if mod == "" {
+		return fmt.Errorf("module is not defined")
+	}
+
+	ctl.mu.Lock()
+	defer ctl.mu.Unlock()
+
+	ctl.cancelRevert(mod)
+	ctl.DeleteModLevel(mod)
+	return nil
+}
+
+// cancelRevert stops pending revert of the target, ctl.mu is held by caller
+func (ctl *Control) cancelRevert(target string) {
+	if t, has := ctl.reverts[target]; has {
+		t.Stop()
+		delete(ctl.reverts, target)
+	}
+}
+
+// scheduleRevert reverts the target after ttl unless the revert is canceled,
+// ctl.mu is held by caller
+func (ctl *Control) scheduleRevert(target string, ttl time.Duration, revert func()) {
+	if ctl.reverts == nil {
+		ctl.reverts = map[string]*time.Timer{}
+	}
+
+	var t *time.Timer
+	t = time.AfterFunc(ttl, func() {
+		ctl.mu.Lock()
+		defer ctl.mu.Unlock()
+
+		if ctl.reverts[target] != t {
+			return
+		}
+		delete(ctl.reverts, target)
+		revert()
+	})
+	ctl.reverts[target] = t
+}
+
+// reverts global level unless it is changed
+func (ctl *Control) revertLevel(level, prev slog.Level) {
+	for {
+		rules := ctl.rules.Load()
+		if rules.level.Level() != level {
+			return
+		}
+
+		if ctl.rules.CompareAndSwap(rules, rules.withLevel(prev)) {
+			return
+		}
+	}
+}
+
+// reverts module rule unless it is changed
+func (ctl *Control) revertModLevel(mod string, level, prev slog.Level, has bool) {
+	ctl.updateMods(func(mods map[string]slog.Level) {
+		if lvl, exists := mods[mod]; !exists || lvl != level {
+			return
+		}
+
+		if has {
+			mods[mod] = prev
+		} else {
+			delete(mods, mod)
+		}
+	})
+}
+
+func (ctl *Control) writeLevels(w http.ResponseWriter) {
+	rules := ctl.rules.Load()
+
+	mods := make(map[string]string, len(rules.mods))
+	for mod, lvl := range rules.mods {
+		mods[mod] = levelName(lvl)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(
+		struct {
+			Level string            `json:"level"`
+			Mods  map[string]string `json:"mods"`
+		}{
+			Level: levelName(rules.level.Level()),
+			Mods:  mods,
+		},
+	)
+}
+
+// parse long or short level name
+func parseLevel(name string) (slog.Level, error) {
+	name = strings.ToUpper(name)
+
+	if lvl, has := longNames[name]; has {
+		return lvl, nil
+	}
+
+	if lvl, has := shortNames[name]; has {
+		return lvl, nil
+	}
+
+	return 0, fmt.Errorf("invalid level %q", name)
+}
+
+func levelName(level slog.Level) string {
+	if name, has := levelLongName[level]; has {
+		return name
+	}
+	return level.String()
+}
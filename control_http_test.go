@@ -0,0 +1,133 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestControlHTTP(t *testing.T) {
+	ctl := NewControl()
+	slog.New(NewJSONHandler(WithWriter(io.Discard), WithControl(ctl),
+		WithLogLevelForMod(map[string]slog.Level{"github.com/fogfish": WARN}),
+	))
+
+	ts := httptest.NewServer(ctl)
+	defer ts.Close()
+
+	type levels struct {
+		Level string            `json:"level"`
+		Mods  map[string]string `json:"mods"`
+	}
+
+	request := func(t *testing.T, method, query string, code int) levels {
+		t.Helper()
+
+		req, err := http.NewRequest(method, ts.URL+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != code {
+			t.Fatalf("unexpected status code %d", resp.StatusCode)
+		}
+
+		var val levels
+		if code == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(&val); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return val
+	}
+
+	t.Run("Get", func(t *testing.T) {
+		val := request(t, http.MethodGet, "", http.StatusOK)
+		if val.Level != "INFO" || val.Mods["github.com/fogfish"] != "WARN" {
+			t.Errorf("unexpected levels %v", val)
+		}
+	})
+
+	t.Run("PutLevel", func(t *testing.T) {
+		val := request(t, http.MethodPut, "?level=DEB", http.StatusOK)
+		if val.Level != "DEBUG" || ctl.Level() != DEBUG {
+			t.Errorf("unexpected levels %v", val)
+		}
+	})
+
+	t.Run("PutModLevel", func(t *testing.T) {
+		val := request(t, http.MethodPut, "?mod=github.com/you/app&level=error", http.StatusOK)
+		if val.Mods["github.com/you/app"] != "ERROR" || ctl.LevelForMod()["github.com/you/app"] != ERROR {
+			t.Errorf("unexpected levels %v", val)
+		}
+	})
+
+	t.Run("DeleteModLevel", func(t *testing.T) {
+		val := request(t, http.MethodDelete, "?mod=github.com/you/app", http.StatusOK)
+		if _, has := val.Mods["github.com/you/app"]; has {
+			t.Errorf("unexpected levels %v", val)
+		}
+	})
+
+	t.Run("TTL", func(t *testing.T) {
+		request(t, http.MethodPut, "?mod=github.com/fogfish&level=DEBUG&ttl=20ms", http.StatusOK)
+		request(t, http.MethodPut, "?level=ERROR&ttl=20ms", http.StatusOK)
+		if ctl.LevelForMod()["github.com/fogfish"] != DEBUG || ctl.Level() != ERROR {
+			t.Errorf("unexpected levels")
+		}
+
+		time.Sleep(100 * time.Millisecond)
+		if ctl.LevelForMod()["github.com/fogfish"] != WARN || ctl.Level() != DEBUG {
+			t.Errorf("levels are not reverted")
+		}
+	})
+
+	t.Run("TTLReplaced", func(t *testing.T) {
+		request(t, http.MethodPut, "?level=INFO", http.StatusOK)
+		request(t, http.MethodPut, "?level=DEBUG&ttl=20ms", http.StatusOK)
+		request(t, http.MethodPut, "?level=DEBUG", http.StatusOK)
+
+		request(t, http.MethodPut, "?mod=github.com/you/app&level=DEBUG&ttl=20ms", http.StatusOK)
+		request(t, http.MethodDelete, "?mod=github.com/you/app", http.StatusOK)
+		request(t, http.MethodPut, "?mod=github.com/you/app&level=DEBUG", http.StatusOK)
+
+		time.Sleep(100 * time.Millisecond)
+		if ctl.Level() != DEBUG || ctl.LevelForMod()["github.com/you/app"] != DEBUG {
+			t.Errorf("levels are reverted by replaced ttl")
+		}
+	})
+
+	t.Run("BadRequest", func(t *testing.T) {
+		request(t, http.MethodPut, "?level=TRACE", http.StatusBadRequest)
+		request(t, http.MethodPut, "?level=INFO&ttl=abc", http.StatusBadRequest)
+		request(t, http.MethodDelete, "", http.StatusBadRequest)
+		request(t, http.MethodPost, "", http.StatusMethodNotAllowed)
+	})
+}
+
+func TestControlHTTPNotAttached(t *testing.T) {
+	w := httptest.NewRecorder()
+	(&Control{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("unexpected status code %d", w.Code)
+	}
+}
@@ -0,0 +1,80 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// levels in the order of cycling from less to more verbose
+var levelCycle = []slog.Level{EMERGENCY, CRITICAL, ERROR, WARN, NOTICE, INFO, DEBUG}
+
+// cycleLevel switches global level to next more verbose one, DEBUG is
+// followed by EMERGENCY
+func (ctl *Control) cycleLevel() {
+	level := ctl.Level()
+
+	next := levelCycle[0]
+	for _, lvl := range levelCycle {
+		if lvl < level {
+			next = lvl
+			break
+		}
+	}
+
+	ctl.SetLevel(next)
+}
+
+// resetLevels restores initial configuration of the handler
+func (ctl *Control) resetLevels(initial *modRules) {
+	ctl.rules.Store(initial)
+}
+
+// reloadLevels re-reads configuration from environment on top of
+// the initial configuration of the handler
+func (ctl *Control) reloadLevels(initial *modRules) {
+	config := &opts{level: initial.level, mods: initial.mods}
+	WithLogLevelFromEnv()(config)
+	WithLogLevelForModFromEnv()(config)
+
+	ctl.rules.Store(newModRules(config.level, config.mods))
+}
+
+// notifyLevels logs the change of configuration, the record is emitted
+// regardless of log levels.
+func notifyLevels(h slog.Handler, signal string, before, after *modRules) {
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:])
+
+	r := slog.NewRecord(time.Now(), NOTICE, "log levels are changed", pcs[0])
+	r.AddAttrs(
+		slog.String("signal", signal),
+		slog.Any("before", before.describe()),
+		slog.Any("after", after.describe()),
+	)
+
+	h.Handle(context.Background(), r)
+}
+
+func (rules *modRules) describe() slog.Value {
+	attrs := []slog.Attr{slog.String("level", levelName(rules.level.Level()))}
+
+	if len(rules.mods) != 0 {
+		mods := make(map[string]string, len(rules.mods))
+		for mod, lvl := range rules.mods {
+			mods[mod] = levelName(lvl)
+		}
+		attrs = append(attrs, slog.Any("mods", mods))
+	}
+
+	return slog.GroupValue(attrs...)
+}
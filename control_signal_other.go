@@ -0,0 +1,16 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+//go:build !unix
+
+package logger
+
+import "log/slog"
+
+// signals are not supported by the platform
+func (ctl *Control) watchSignals(h slog.Handler, initial *modRules) {}
@@ -0,0 +1,89 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestControlCycleLevel(t *testing.T) {
+	ctl := NewControl()
+	slog.New(NewJSONHandler(WithWriter(io.Discard), WithLogLevel(INFO), WithControl(ctl)))
+
+	for _, expected := range []slog.Level{DEBUG, EMERGENCY, CRITICAL, ERROR, WARN, NOTICE, INFO} {
+		ctl.cycleLevel()
+		if ctl.Level() != expected {
+			t.Errorf("unexpected level %s, expected %s", ctl.Level(), expected)
+		}
+	}
+}
+
+func TestControlResetLevels(t *testing.T) {
+	ctl := NewControl()
+	slog.New(NewJSONHandler(WithWriter(io.Discard), WithLogLevel(WARN), WithControl(ctl),
+		WithLogLevelForMod(map[string]slog.Level{"github.com/fogfish": ERROR}),
+	))
+	initial := ctl.rules.Load()
+
+	ctl.SetLevel(DEBUG)
+	ctl.SetLevelForMod(nil)
+	ctl.resetLevels(initial)
+
+	if ctl.Level() != WARN || ctl.LevelForMod()["github.com/fogfish"] != ERROR {
+		t.Errorf("levels are not reset")
+	}
+}
+
+func TestControlReloadLevels(t *testing.T) {
+	ctl := NewControl()
+	slog.New(NewJSONHandler(WithWriter(io.Discard), WithLogLevel(WARN), WithControl(ctl),
+		WithLogLevelForMod(map[string]slog.Level{"github.com/fogfish": ERROR}),
+	))
+	initial := ctl.rules.Load()
+
+	t.Run("Unchanged", func(t *testing.T) {
+		ctl.reloadLevels(initial)
+		if ctl.Level() != WARN || ctl.LevelForMod()["github.com/fogfish"] != ERROR {
+			t.Errorf("unexpected levels")
+		}
+	})
+
+	t.Run("FromEnv", func(t *testing.T) {
+		t.Setenv("CONFIG_LOG_LEVEL", "DEBUG")
+		t.Setenv("CONFIG_LOG_LEVEL_NOTICE", "github.com/you/app")
+
+		ctl.reloadLevels(initial)
+		mods := ctl.LevelForMod()
+		if ctl.Level() != DEBUG || mods["github.com/you/app"] != NOTICE || len(mods) != 1 {
+			t.Errorf("unexpected levels %s %v", ctl.Level(), mods)
+		}
+	})
+}
+
+func TestNotifyLevels(t *testing.T) {
+	b := &bytes.Buffer{}
+	h := NewJSONHandler(WithWriter(b))
+
+	notifyLevels(h, "hangup",
+		newModRules(INFO, nil),
+		newModRules(DEBUG, map[string]slog.Level{"github.com/fogfish": WARN}),
+	)
+
+	txt := b.String()
+	if !strings.Contains(txt, `"level":"NOTICE"`) ||
+		!strings.Contains(txt, `"signal":"hangup"`) ||
+		!strings.Contains(txt, `"before":{"level":"INFO"}`) ||
+		!strings.Contains(txt, `"after":{"level":"DEBUG","mods":{"github.com/fogfish":"WARN"}}`) {
+		t.Errorf("unexpected log line %s", txt)
+	}
+}
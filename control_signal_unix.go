@@ -0,0 +1,45 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+//go:build unix
+
+package logger
+
+import (
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// watchSignals changes log levels on signals
+//
+//	SIGUSR1 cycles the global level down through 7 levels
+//	SIGUSR2 resets to initial configuration
+//	SIGHUP re-reads CONFIG_LOG_LEVEL and CONFIG_LOG_LEVEL_* variables
+func (ctl *Control) watchSignals(h slog.Handler, initial *modRules) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGUSR1, syscall.SIGUSR2, syscall.SIGHUP)
+
+	go func() {
+		for sig := range ch {
+			before := ctl.rules.Load()
+
+			switch sig {
+			case syscall.SIGUSR1:
+				ctl.cycleLevel()
+			case syscall.SIGUSR2:
+				ctl.resetLevels(initial)
+			case syscall.SIGHUP:
+				ctl.reloadLevels(initial)
+			}
+
+			notifyLevels(h, sig.String(), before, ctl.rules.Load())
+		}
+	}()
+}
@@ -0,0 +1,43 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+//go:build unix
+
+package logger
+
+import (
+	"io"
+	"log/slog"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSignalControl(t *testing.T) {
+	ctl := NewControl()
+	slog.New(NewJSONHandler(WithWriter(io.Discard), WithLogLevel(INFO), WithControl(ctl), WithSignalControl()))
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	waitLevel(t, ctl, DEBUG)
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)
+	waitLevel(t, ctl, INFO)
+}
+
+func waitLevel(t *testing.T, ctl *Control, level slog.Level) {
+	t.Helper()
+
+	for i := 0; i < 100; i++ {
+		if ctl.Level() == level {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Errorf("unexpected level %s, expected %s", ctl.Level(), level)
+}
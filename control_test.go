@@ -0,0 +1,116 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+)
+
+func TestControl(t *testing.T) {
+	b := &bytes.Buffer{}
+
+	for name, handler := range map[string]func(...Option) slog.Handler{
+		"JSON":  NewJSONHandler,
+		"Stdio": NewStdioHandler,
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctl := NewControl()
+			log := slog.New(handler(WithWriter(b), WithLogLevel(INFO), WithControl(ctl)))
+			sub := log.With("a", 1).WithGroup("g")
+
+			t.Run("Default", func(t *testing.T) {
+				defer b.Reset()
+
+				if ctl.Level() != INFO {
+					t.Errorf("unexpected level %s", ctl.Level())
+				}
+
+				sub.Debug("test")
+				if b.Len() != 0 {
+					t.Errorf("unexpected log line %s", b.String())
+				}
+			})
+
+			t.Run("SetLevel", func(t *testing.T) {
+				defer b.Reset()
+				defer ctl.SetLevel(INFO)
+
+				ctl.SetLevel(DEBUG)
+				sub.Debug("test")
+				if b.Len() == 0 {
+					t.Errorf("expected log line")
+				}
+			})
+
+			t.Run("SetLevelForMod", func(t *testing.T) {
+				defer b.Reset()
+				defer ctl.SetLevelForMod(nil)
+
+				ctl.SetLevelForMod(map[string]slog.Level{testMod(): DEBUG})
+				if mods := ctl.LevelForMod(); len(mods) != 1 || mods[testMod()] != DEBUG {
+					t.Errorf("unexpected module rules %v", mods)
+				}
+
+				sub.Debug("test")
+				if b.Len() == 0 {
+					t.Errorf("expected log line")
+				}
+
+				ctl.SetLevelForMod(map[string]slog.Level{testMod(): WARN})
+				b.Reset()
+				sub.Info("test")
+				if b.Len() != 0 {
+					t.Errorf("unexpected log line %s", b.String())
+				}
+			})
+		})
+	}
+}
+
+func TestNewWithControl(t *testing.T) {
+	log, ctl := NewWithControl(WithWriter(io.Discard), WithLogLevel(WARN))
+
+	if log.Enabled(context.Background(), INFO) {
+		t.Errorf("unexpected INFO level")
+	}
+
+	ctl.SetLevel(INFO)
+	if !log.Enabled(context.Background(), INFO) {
+		t.Errorf("expected INFO level")
+	}
+}
+
+func TestControlConcurrent(t *testing.T) {
+	ctl := NewControl()
+	log := slog.New(NewJSONHandler(WithWriter(io.Discard), WithControl(ctl)))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				log.Info("test", "i", i)
+			}
+		}()
+	}
+
+	for i := 0; i < 100; i++ {
+		ctl.SetLevel(DEBUG)
+		ctl.SetLevelForMod(map[string]slog.Level{testMod(): WARN})
+		ctl.SetLevel(INFO)
+	}
+
+	wg.Wait()
+}
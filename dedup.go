@@ -0,0 +1,308 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DedupHandler suppresses identical records within the window, the first
+// record is logged, the summary is logged at the end of window if records
+// are repeated. Close has to be called before the process exits.
+type DedupHandler struct {
+	slog.Handler
+	dedup *dedup
+}
+
+// The config option of duplicate suppression handler
+type DedupOption func(*dedupOpts)
+
+type dedupOpts struct {
+	window time.Duration
+	attrs  []string
+}
+
+// Duplicate suppression logger handler, records are identical if they have
+// same level, message, source code location and values of selected
+// attributes (see WithDedupAttrs).
+//
+//	h := logger.NewDedupHandler(logger.NewJSONHandler(), logger.WithDedupWindow(30*time.Second))
+//	defer h.Close()
+//
+// The summary record has the message of repeated record and the attribute
+// repeated, e.g. "connection failed (repeated 4,213 times in 30s)". The window
+// is 10 seconds by default. EMERGENCY records are never suppressed.
+func NewDedupHandler(h slog.Handler, opts ...DedupOption) *DedupHandler {
+	config := &dedupOpts{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	if config.window <= 0 {
+		config.window = 10 * time.Second
+	}
+
+	d := &dedup{
+		window: config.window,
+		attrs:  config.attrs,
+		keys:   map[dedupKey]*dedupState{},
+	}
+
+	return &DedupHandler{
+		Handler: &dedupHandler{Handler: h, dedup: d},
+		dedup:   d,
+	}
+}
+
+// Close logs summaries of repeated records, records logged after Close are
+// not suppressed.
+func (h *DedupHandler) Close() error {
+	return h.dedup.close()
+}
+
+// Config window of duplicate suppression, default 10 seconds
+func WithDedupWindow(window time.Duration) DedupOption {
+	return func(o *dedupOpts) {
+		o.window = window
+	}
+}
+
+// Config attributes distinguishing records for duplicate suppression, records
+// are identical if they have same level, message, source code location and
+// values of the attributes, either of the record or defined via WithAttrs.
+//
+//	log.WithDedupAttrs("host", "status")
+func WithDedupAttrs(keys ...string) DedupOption {
+	return func(o *dedupOpts) {
+		o.attrs = append(o.attrs, keys...)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type dedupHandler struct {
+	slog.Handler
+	dedup *dedup
+	attrs []slog.Attr // attributes defined via WithAttrs, used by the key
+}
+
+func (h *dedupHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	return &dedupHandler{
+		Handler: h.Handler.WithAttrs(attrs),
+		dedup:   h.dedup,
+		attrs:   append(h.attrs[:len(h.attrs):len(h.attrs)], attrs...),
+	}
+}
+
+func (h *dedupHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	return &dedupHandler{Handler: h.Handler.WithGroup(name), dedup: h.dedup, attrs: h.attrs}
+}
+
+func (h *dedupHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= EMERGENCY {
+		return h.Handler.Handle(ctx, r)
+	}
+
+	if !h.dedup.first(h.Handler, h.attrs, r) {
+		return nil
+	}
+
+	return h.Handler.Handle(ctx, r)
+}
+
+//------------------------------------------------------------------------------
+
+type dedupKey struct {
+	level slog.Level
+	pc    uintptr
+	msg   string
+	attrs string
+}
+
+// dedupState is the window of repeated record
+type dedupState struct {
+	h        slog.Handler
+	r        slog.Record
+	start    time.Time
+	repeated int
+	timer    *time.Timer
+	elem     *list.Element // the position at the list of windows
+}
+
+// The number of records tracked by dedup, the oldest window is closed
+// and its summary is logged if exceeded
+const dedupMaxKeys = 1024
+
+type dedup struct {
+	sync.Mutex
+	window time.Duration
+	attrs  []string
+	keys   map[dedupKey]*dedupState
+	order  list.List // keys of windows, the oldest window is first
+	closed bool
+}
+
+// keyOf builds the key from selected attributes of the record and attributes
+// defined via WithAttrs
+func (d *dedup) keyOf(attrs []slog.Attr, r slog.Record) dedupKey {
+	key := dedupKey{level: r.Level, pc: r.PC, msg: r.Message}
+	if len(d.attrs) == 0 {
+		return key
+	}
+
+	var sb strings.Builder
+	f := func(a slog.Attr) bool {
+		for _, k := range d.attrs {
+			if a.Key == k {
+				sb.WriteString(a.Key)
+				sb.WriteByte('=')
+				sb.WriteString(a.Value.Resolve().String())
+				sb.WriteByte(0)
+			}
+		}
+		return true
+	}
+
+	for _, a := range attrs {
+		f(a)
+	}
+	r.Attrs(f)
+	key.attrs = sb.String()
+
+	return key
+}
+
+// first returns true if the record is first within the window
+func (d *dedup) first(h slog.Handler, attrs []slog.Attr, r slog.Record) bool {
+	key := d.keyOf(attrs, r)
+
+	d.Lock()
+
+	if d.closed {
+		d.Unlock()
+		return true
+	}
+
+	if state, has := d.keys[key]; has {
+		state.repeated++
+		d.Unlock()
+		return false
+	}
+
+	var evicted *dedupState
+	if len(d.keys) >= dedupMaxKeys {
+		evicted = d.remove(d.order.Front().Value.(dedupKey))
+		evicted.timer.Stop()
+	}
+
+	state := &dedupState{h: h, r: r.Clone(), start: time.Now()}
+	state.timer = time.AfterFunc(d.window, func() { d.expire(key, state) })
+	state.elem = d.order.PushBack(key)
+	d.keys[key] = state
+	d.Unlock()
+
+	if evicted != nil {
+		d.summary(evicted, time.Since(evicted.start))
+	}
+
+	return true
+}
+
+func (d *dedup) expire(key dedupKey, state *dedupState) {
+	d.Lock()
+	if d.keys[key] != state {
+		d.Unlock()
+		return
+	}
+	d.remove(key)
+	d.Unlock()
+
+	d.summary(state, d.window)
+}
+
+func (d *dedup) remove(key dedupKey) *dedupState {
+	state := d.keys[key]
+	delete(d.keys, key)
+	d.order.Remove(state.elem)
+	return state
+}
+
+// summary logs the number of repeated records within the period
+func (d *dedup) summary(state *dedupState, period time.Duration) error {
+	if state.repeated == 0 {
+		return nil
+	}
+
+	msg := state.r.Message + " (repeated " + formatCount(state.repeated) + " times in " + period.String() + ")"
+	r := slog.NewRecord(time.Now(), state.r.Level, msg, state.r.PC)
+	state.r.Attrs(func(a slog.Attr) bool {
+		r.AddAttrs(a)
+		return true
+	})
+	r.AddAttrs(slog.Int("repeated", state.repeated))
+
+	return state.h.Handle(context.Background(), r)
+}
+
+func (d *dedup) close() error {
+	d.Lock()
+	d.closed = true
+	keys := d.keys
+	d.keys = map[dedupKey]*dedupState{}
+	d.order.Init()
+	d.Unlock()
+
+	// timers are no-op once keys are removed
+	var errs []error
+	for _, state := range keys {
+		state.timer.Stop()
+		if err := d.summary(state, time.Since(state.start)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// formatCount formats number with thousands separator, e.g. 4,213
+func formatCount(n int) string {
+	s := strconv.Itoa(n)
+	if len(s) <= 3 {
+		return s
+	}
+
+	var sb strings.Builder
+	pre := len(s) % 3
+	if pre > 0 {
+		sb.WriteString(s[:pre])
+	}
+	for i := pre; i < len(s); i += 3 {
+		if sb.Len() > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(s[i : i+3])
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,182 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is buffer safe for concurrent use, the summary is logged by timer
+type syncBuffer struct {
+	sync.Mutex
+	b bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	return b.b.Write(p)
+}
+
+func (b *syncBuffer) lines() []string {
+	b.Lock()
+	defer b.Unlock()
+	return strings.Split(strings.TrimSpace(b.b.String()), "\n")
+}
+
+func TestFormatCount(t *testing.T) {
+	for n, expected := range map[int]string{
+		1:       "1",
+		999:     "999",
+		1000:    "1,000",
+		4213:    "4,213",
+		123456:  "123,456",
+		1234567: "1,234,567",
+	} {
+		if v := formatCount(n); v != expected {
+			t.Errorf("unexpected format %s of %d", v, n)
+		}
+	}
+}
+
+func TestDedupHandler(t *testing.T) {
+	t.Run("Close", func(t *testing.T) {
+		b := &syncBuffer{}
+		h := NewDedupHandler(NewJSONHandler(WithWriter(b)), WithDedupWindow(time.Hour))
+		log := slog.New(h)
+
+		for i := 0; i < 1001; i++ {
+			log.Error("failed", "i", i)
+		}
+		log.Error("other")
+
+		if err := h.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		lines := b.lines()
+		if len(lines) != 3 ||
+			!strings.Contains(lines[0], `"msg":"failed","i":0`) ||
+			!strings.Contains(lines[1], `"msg":"other"`) ||
+			!strings.Contains(lines[2], `"msg":"failed (repeated 1,000 times in `) ||
+			!strings.Contains(lines[2], `"i":0,"repeated":1000`) ||
+			strings.Contains(lines[2], ` in 0s)`) {
+			t.Errorf("unexpected log lines %s", lines)
+		}
+
+		log.Error("failed")
+		if lines := b.lines(); len(lines) != 4 {
+			t.Errorf("records are suppressed after close %s", lines)
+		}
+	})
+
+	t.Run("MaxKeys", func(t *testing.T) {
+		b := &syncBuffer{}
+		h := NewDedupHandler(NewJSONHandler(WithWriter(b)), WithDedupWindow(time.Hour))
+		defer h.Close()
+		log := slog.New(h)
+
+		for i := 0; i < 2; i++ {
+			log.Error("failed", "i", 0)
+		}
+		for i := 0; i < dedupMaxKeys; i++ {
+			log.Error("other " + strconv.Itoa(i))
+		}
+
+		if n := len(h.dedup.keys); n != dedupMaxKeys {
+			t.Errorf("unexpected number of keys %d", n)
+		}
+
+		lines := b.lines()
+		if len(lines) != dedupMaxKeys+2 ||
+			!strings.Contains(lines[dedupMaxKeys], `"msg":"failed (repeated 1 times in `) ||
+			!strings.Contains(lines[dedupMaxKeys+1], `"msg":"other 1023"`) {
+			t.Errorf("unexpected log lines %s", lines[dedupMaxKeys-1:])
+		}
+	})
+
+	t.Run("Window", func(t *testing.T) {
+		b := &syncBuffer{}
+		h := NewDedupHandler(NewJSONHandler(WithWriter(b)), WithDedupWindow(20*time.Millisecond))
+		defer h.Close()
+		log := slog.New(h)
+
+		for i := 0; i < 3; i++ {
+			log.Warn("failed")
+		}
+
+		time.Sleep(100 * time.Millisecond)
+		log.Warn("failed")
+
+		lines := b.lines()
+		if len(lines) != 3 ||
+			!strings.Contains(lines[1], `"msg":"failed (repeated 2 times in 20ms)"`) ||
+			!strings.Contains(lines[2], `"msg":"failed"`) {
+			t.Errorf("unexpected log lines %s", lines)
+		}
+	})
+
+	t.Run("Attrs", func(t *testing.T) {
+		b := &syncBuffer{}
+		h := NewDedupHandler(NewJSONHandler(WithWriter(b)), WithDedupWindow(time.Hour), WithDedupAttrs("host"))
+		defer h.Close()
+		log := slog.New(h)
+
+		for i, host := range []string{"a", "b", "a", "b"} {
+			log.Error("failed", "host", host, "i", i)
+		}
+
+		if lines := b.lines(); len(lines) != 2 {
+			t.Errorf("unexpected log lines %s", lines)
+		}
+	})
+
+	t.Run("WithAttrs", func(t *testing.T) {
+		b := &syncBuffer{}
+		h := NewDedupHandler(NewJSONHandler(WithWriter(b)), WithDedupWindow(time.Hour), WithDedupAttrs("host"))
+		log := slog.New(h)
+
+		for _, host := range []string{"a", "b", "c", "a"} {
+			log.With("host", host).Error("failed")
+		}
+		h.Close()
+
+		lines := b.lines()
+		if len(lines) != 4 ||
+			!strings.Contains(lines[0], `"msg":"failed","host":"a"`) ||
+			!strings.Contains(lines[1], `"msg":"failed","host":"b"`) ||
+			!strings.Contains(lines[2], `"msg":"failed","host":"c"`) ||
+			!strings.Contains(lines[3], `"msg":"failed (repeated 1 times in `) ||
+			!strings.Contains(lines[3], `"host":"a","repeated":1`) {
+			t.Errorf("unexpected log lines %s", lines)
+		}
+	})
+
+	t.Run("Level", func(t *testing.T) {
+		b := &syncBuffer{}
+		h := NewDedupHandler(NewJSONHandler(WithWriter(b)), WithDedupWindow(time.Hour))
+		defer h.Close()
+		log := slog.New(h)
+
+		for _, level := range []slog.Level{ERROR, CRITICAL, EMERGENCY, EMERGENCY, ERROR} {
+			log.Log(context.Background(), level, "failed")
+		}
+
+		if lines := b.lines(); len(lines) != 4 {
+			t.Errorf("unexpected log lines %s", lines)
+		}
+	})
+}
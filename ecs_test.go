@@ -0,0 +1,106 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+type stackError struct{ error }
+
+func (e stackError) Format(s fmt.State, verb rune) {
+	fmt.Fprint(s, e.Error())
+	if s.Flag('+') {
+		fmt.Fprint(s, "\nmain.main\n\tmain.go:10")
+	}
+}
+
+func TestECSHandler(t *testing.T) {
+	b := &bytes.Buffer{}
+	log := slog.New(NewECSHandler(WithWriter(b)))
+
+	decode := func(t *testing.T) map[string]any {
+		t.Helper()
+
+		var val map[string]any
+		if err := json.Unmarshal(b.Bytes(), &val); err != nil {
+			t.Fatalf("invalid json %s", b.String())
+		}
+		return val
+	}
+
+	t.Run("Record", func(t *testing.T) {
+		defer b.Reset()
+
+		log.Log(context.Background(), NOTICE, "test", "a", 1)
+		val := decode(t)
+
+		if val["log.level"] != "notice" || val["message"] != "test" || val["a"] != 1.0 || val["ecs.version"] != ecsVersion {
+			t.Errorf("unexpected log line %s", b.String())
+		}
+
+		if _, err := time.Parse(time.RFC3339Nano, val["@timestamp"].(string)); err != nil {
+			t.Errorf("unexpected timestamp %s", b.String())
+		}
+
+		origin, ok := val["log.origin"].(map[string]any)
+		if !ok {
+			t.Fatalf("unexpected log origin %s", b.String())
+		}
+		file := origin["file"].(map[string]any)
+		if !strings.HasSuffix(file["name"].(string), "ecs_test.go") || file["line"] == 0.0 ||
+			!strings.HasSuffix(origin["function"].(string), "TestECSHandler.func2") {
+			t.Errorf("unexpected log origin %s", b.String())
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		defer b.Reset()
+
+		log.Error("test", "err", errors.New("failed"))
+		val := decode(t)
+
+		e, ok := val["error"].(map[string]any)
+		if !ok || e["message"] != "failed" || e["type"] != "*errors.errorString" || e["stack_trace"] != nil {
+			t.Errorf("unexpected error %s", b.String())
+		}
+	})
+
+	t.Run("StackTrace", func(t *testing.T) {
+		defer b.Reset()
+
+		log.Error("test", "error", stackError{errors.New("failed")})
+		val := decode(t)
+
+		e, ok := val["error"].(map[string]any)
+		if !ok || e["message"] != "failed" || e["stack_trace"] != "failed\nmain.main\n\tmain.go:10" {
+			t.Errorf("unexpected error %s", b.String())
+		}
+	})
+
+	t.Run("Group", func(t *testing.T) {
+		defer b.Reset()
+
+		log.WithGroup("g").Info("test", "message", "x", "err", "y")
+		val := decode(t)
+
+		g, ok := val["g"].(map[string]any)
+		if !ok || g["message"] != "x" || g["err"] != "y" || val["message"] != "test" {
+			t.Errorf("unexpected group %s", b.String())
+		}
+	})
+}
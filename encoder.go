@@ -0,0 +1,487 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+//------------------------------------------------------------------------------
+
+// buffer is pooled byte slice used by handlers to encode records
+type buffer []byte
+
+var bufPool = sync.Pool{
+	New: func() any {
+		b := make(buffer, 0, 1024)
+		return &b
+	},
+}
+
+func newBuffer() *buffer {
+	return bufPool.Get().(*buffer)
+}
+
+func (b *buffer) free() {
+	// Do not keep large buffers in the pool, it is a memory leak
+	const maxBufferSize = 16 << 10
+	if cap(*b) <= maxBufferSize {
+		*b = (*b)[:0]
+		bufPool.Put(b)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// appendText appends value as plain text, used for time, level and message.
+func appendText(buf []byte, v slog.Value) []byte {
+	switch v.Kind() {
+	case slog.KindString:
+		return append(buf, v.String()...)
+	case slog.KindTime:
+		return v.Time().AppendFormat(buf, time.RFC3339Nano)
+	default:
+		return append(buf, v.String()...)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// groupOrAttrs is either a group or attributes defined by WithGroup, WithAttrs
+type groupOrAttrs struct {
+	group string
+	attrs []slog.Attr
+}
+
+// appendGroupOrAttrs returns copy of the sequence with new group or attributes
+func appendGroupOrAttrs(goas []groupOrAttrs, goa groupOrAttrs) []groupOrAttrs {
+	seq := make([]groupOrAttrs, len(goas)+1)
+	copy(seq, goas)
+	seq[len(goas)] = goa
+	return seq
+}
+
+// applyGroupOrAttrs re-applies the sequence of groups and attributes to
+// the handler
+func applyGroupOrAttrs(h slog.Handler, goas []groupOrAttrs) slog.Handler {
+	for _, goa := range goas {
+		if goa.group != "" {
+			h = h.WithGroup(goa.group)
+		} else {
+			h = h.WithAttrs(goa.attrs)
+		}
+	}
+	return h
+}
+
+//------------------------------------------------------------------------------
+
+// jsonEncoder encodes attributes as indented JSON object, it is equivalent
+// to json.MarshalIndent(..., "", "  ") but keeps the order of attributes.
+// The compact encoder skips indentation.
+type jsonEncoder struct {
+	buf     []byte
+	compact bool
+	path    []string // groups of current object
+	pinned  []string // top-level keys written ahead of other attributes
+	special []string // top-level keys written by handler, attributes are skipped
+	depth   int
+	n       int // number of fields written into current object
+}
+
+// appendRecordAttrs appends attributes of the record and attributes defined
+// via WithAttrs, WithGroup. Attributes of the record are written first,
+// followed by attributes of the handler, nested groups are written last:
+//
+//	log.With("a", 1).WithGroup("g").With("b", 2).Info("msg", "c", 3)
+//	{"a": 1, "g": {"c": 3, "b": 2}}
+func (enc *jsonEncoder) appendRecordAttrs(attrs Attributes, goas []groupOrAttrs, r slog.Record) {
+	at := len(goas)
+	for at > 0 && goas[at-1].group == "" {
+		at--
+	}
+
+	enc.appendGroups(attrs, goas[:at], func() {
+		r.Attrs(func(a slog.Attr) bool {
+			enc.appendAttr(attrs, a)
+			return true
+		})
+		for _, goa := range goas[at:] {
+			for _, a := range goa.attrs {
+				enc.appendAttr(attrs, a)
+			}
+		}
+	})
+}
+
+func (enc *jsonEncoder) appendGroups(attrs Attributes, goas []groupOrAttrs, f func()) {
+	if len(goas) == 0 {
+		f()
+		return
+	}
+
+	goa := goas[0]
+	if goa.group == "" {
+		for _, a := range goa.attrs {
+			enc.appendAttr(attrs, a)
+		}
+		enc.appendGroups(attrs, goas[1:], f)
+		return
+	}
+
+	enc.appendGroup(goa.group, func() {
+		enc.appendGroups(attrs, goas[1:], f)
+	})
+}
+
+// appendPinnedAttrs appends top-level attributes with given keys. Once
+// written, pinned attributes are skipped by the encoder. The source is
+// pinned by its key, it is omitted if the source is not logged.
+func (enc *jsonEncoder) appendPinnedAttrs(attrs Attributes, keys []string, goas []groupOrAttrs, r slog.Record, source slog.Attr) {
+	grouped := false
+	for _, goa := range goas {
+		grouped = grouped || goa.group != ""
+	}
+
+	for _, key := range keys {
+		if source.Key != "" && source.Key == key {
+			enc.appendAttr(attrs, source)
+		}
+
+		if !grouped {
+			r.Attrs(func(a slog.Attr) bool {
+				enc.appendPinnedAttr(attrs, key, a)
+				return true
+			})
+		}
+
+		for _, goa := range goas {
+			if goa.group != "" {
+				break
+			}
+			for _, a := range goa.attrs {
+				enc.appendPinnedAttr(attrs, key, a)
+			}
+		}
+	}
+
+	enc.pinned = keys
+}
+
+// appendPinnedAttr appends the attribute if it has the key, attributes of
+// inline groups are top-level ones.
+func (enc *jsonEncoder) appendPinnedAttr(attrs Attributes, key string, a slog.Attr) {
+	if a.Key == key {
+		enc.appendAttr(attrs, a)
+		return
+	}
+
+	if a.Key == "" {
+		if v := a.Value.Resolve(); v.Kind() == slog.KindGroup {
+			for _, x := range v.Group() {
+				enc.appendPinnedAttr(attrs, key, x)
+			}
+		}
+	}
+}
+
+// isSkipped returns true if the top-level attribute is either pinned or
+// special one
+func (enc *jsonEncoder) isSkipped(key string) bool {
+	for _, k := range enc.pinned {
+		if k == key {
+			return true
+		}
+	}
+	for _, k := range enc.special {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// appendAttr appends attribute as JSON field, it applies Attributes
+// combinators to each non-group attribute.
+func (enc *jsonEncoder) appendAttr(attrs Attributes, a slog.Attr) {
+	if len(enc.path) == 0 && enc.isSkipped(a.Key) {
+		return
+	}
+
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() != slog.KindGroup {
+		a = attrs.handle(enc.path, a)
+		a.Value = a.Value.Resolve()
+	}
+
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() == slog.KindAny {
+		if src, ok := a.Value.Any().(*slog.Source); ok {
+			if src == nil || (src.File == "" && src.Line == 0 && src.Function == "") {
+				return
+			}
+			a.Value = slog.GroupValue(
+				slog.String("file", src.File),
+				slog.String("function", src.Function),
+				slog.Int("line", src.Line),
+			)
+		}
+	}
+
+	if a.Value.Kind() != slog.KindGroup {
+		enc.appendKey(a.Key)
+		enc.appendValue(a.Value)
+		return
+	}
+
+	group := a.Value.Group()
+	if len(group) == 0 {
+		return
+	}
+
+	if a.Key == "" {
+		for _, x := range group {
+			enc.appendAttr(attrs, x)
+		}
+		return
+	}
+
+	enc.appendGroup(a.Key, func() {
+		for _, x := range group {
+			enc.appendAttr(attrs, x)
+		}
+	})
+}
+
+// appendGroup opens nested object, the object is rolled back if empty
+func (enc *jsonEncoder) appendGroup(key string, f func()) {
+	at, n := len(enc.buf), enc.n
+	enc.appendKey(key)
+	enc.buf = append(enc.buf, '{')
+
+	enc.path = append(enc.path, key)
+	enc.depth, enc.n = enc.depth+1, 0
+	f()
+	enc.depth, enc.path = enc.depth-1, enc.path[:len(enc.path)-1]
+
+	if enc.n == 0 {
+		enc.buf, enc.n = enc.buf[:at], n
+		return
+	}
+
+	enc.n = n + 1
+	enc.newline()
+	enc.buf = append(enc.buf, '}')
+}
+
+func (enc *jsonEncoder) appendKey(key string) {
+	if enc.n > 0 {
+		enc.buf = append(enc.buf, ',')
+	}
+	enc.n++
+	enc.newline()
+	enc.buf = appendJSONString(enc.buf, key)
+	enc.buf = append(enc.buf, ':')
+	if !enc.compact {
+		enc.buf = append(enc.buf, ' ')
+	}
+}
+
+func (enc *jsonEncoder) newline() {
+	if enc.compact {
+		return
+	}
+
+	enc.buf = append(enc.buf, '\n')
+	for i := 0; i < enc.depth; i++ {
+		enc.buf = append(enc.buf, ' ', ' ')
+	}
+}
+
+func (enc *jsonEncoder) appendValue(v slog.Value) {
+	switch v.Kind() {
+	case slog.KindString:
+		enc.buf = appendJSONString(enc.buf, v.String())
+	case slog.KindInt64:
+		enc.buf = strconv.AppendInt(enc.buf, v.Int64(), 10)
+	case slog.KindUint64:
+		enc.buf = strconv.AppendUint(enc.buf, v.Uint64(), 10)
+	case slog.KindFloat64:
+		f := v.Float64()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			enc.buf = appendJSONString(enc.buf, strconv.FormatFloat(f, 'g', -1, 64))
+			return
+		}
+		enc.buf = strconv.AppendFloat(enc.buf, f, 'g', -1, 64)
+	case slog.KindBool:
+		enc.buf = strconv.AppendBool(enc.buf, v.Bool())
+	case slog.KindDuration:
+		enc.buf = strconv.AppendInt(enc.buf, int64(v.Duration()), 10)
+	case slog.KindTime:
+		enc.buf = append(enc.buf, '"')
+		enc.buf = v.Time().AppendFormat(enc.buf, time.RFC3339Nano)
+		enc.buf = append(enc.buf, '"')
+	default:
+		enc.appendAny(v.Any())
+	}
+}
+
+func (enc *jsonEncoder) appendAny(v any) {
+	if err, ok := v.(error); ok {
+		if _, ok := v.(json.Marshaler); !ok {
+			enc.buf = appendJSONString(enc.buf, err.Error())
+			return
+		}
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		enc.buf = appendJSONString(enc.buf, "!ERROR:"+err.Error())
+		return
+	}
+
+	if enc.compact {
+		enc.buf = append(enc.buf, data...)
+		return
+	}
+
+	var b bytes.Buffer
+	prefix := make([]byte, 2*enc.depth)
+	for i := range prefix {
+		prefix[i] = ' '
+	}
+	if err := json.Indent(&b, data, string(prefix), "  "); err != nil {
+		enc.buf = append(enc.buf, data...)
+		return
+	}
+	enc.buf = append(enc.buf, b.Bytes()...)
+}
+
+//------------------------------------------------------------------------------
+
+// walkFlatAttrs walks attributes defined via WithAttrs, WithGroup and
+// attributes of the record, nested groups are flattened into dotted keys.
+// Attributes combinators are applied to each non-group attribute.
+func walkFlatAttrs(attrs Attributes, goas []groupOrAttrs, r slog.Record, f func(key string, v slog.Value)) {
+	w := flatWalker{attrs: attrs, f: f}
+	for _, goa := range goas {
+		if goa.group != "" {
+			w.open(goa.group)
+			continue
+		}
+		for _, a := range goa.attrs {
+			w.walk(a)
+		}
+	}
+
+	r.Attrs(func(a slog.Attr) bool {
+		w.walk(a)
+		return true
+	})
+}
+
+type flatWalker struct {
+	attrs  Attributes
+	path   []string
+	prefix string
+	f      func(key string, v slog.Value)
+}
+
+func (w *flatWalker) open(group string) {
+	w.prefix = w.prefix + group + "."
+	w.path = append(w.path[:len(w.path):len(w.path)], group)
+}
+
+func (w *flatWalker) walk(a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() != slog.KindGroup {
+		a = w.attrs.handle(w.path, a)
+		a.Value = a.Value.Resolve()
+	}
+
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() != slog.KindGroup {
+		w.f(w.prefix+a.Key, a.Value)
+		return
+	}
+
+	if a.Key == "" {
+		for _, x := range a.Value.Group() {
+			w.walk(x)
+		}
+		return
+	}
+
+	prefix, path := w.prefix, w.path
+	w.open(a.Key)
+	for _, x := range a.Value.Group() {
+		w.walk(x)
+	}
+	w.prefix, w.path = prefix, path
+}
+
+//------------------------------------------------------------------------------
+
+const hex = "0123456789abcdef"
+
+// appendJSONString appends quoted and escaped string
+func appendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' {
+				i++
+				continue
+			}
+			buf = append(buf, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				buf = append(buf, '\\', c)
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, `\ufffd`...)
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf = append(buf, s[start:]...)
+	return append(buf, '"')
+}
@@ -0,0 +1,202 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"runtime"
+
+	"github.com/fogfish/logger/v3/internal/trie"
+)
+
+// Route is destination of fan-out handler, the record is passed to
+// the handler if its level is at least Level and Match accepts it.
+type Route struct {
+	Handler slog.Handler
+	Level   slog.Leveler // optional, all records enabled by Handler if nil
+	Match   Predicate    // optional, all records if nil
+}
+
+// Predicate selects records routed to the destination
+type Predicate func(ctx context.Context, r slog.Record) bool
+
+// Fan-out handler sends records to multiple destinations, e.g. console for
+// humans, JSON for shipping and ERROR+ to the alerting sink:
+//
+//	logger.NewFanOutHandler(
+//		logger.Route{Handler: logger.NewStdioHandler()},
+//		logger.Route{Handler: logger.NewJSONHandler(logger.WithWriter(file))},
+//		logger.Route{Handler: alerts, Level: logger.ERROR},
+//	)
+//
+// Attributes and groups are propagated to every destination, errors of
+// destinations are joined.
+func NewFanOutHandler(routes ...Route) slog.Handler {
+	return &fanOutHandler{
+		routes: append([]Route(nil), routes...),
+	}
+}
+
+type fanOutHandler struct {
+	routes  []Route
+	attrs   []slog.Attr // top-level attributes defined via WithAttrs, used by predicates
+	grouped bool
+}
+
+func (route *Route) enabled(ctx context.Context, level slog.Level) bool {
+	if route.Level != nil && level < route.Level.Level() {
+		return false
+	}
+	return route.Handler.Enabled(ctx, level)
+}
+
+func (h *fanOutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for i := range h.routes {
+		if h.routes[i].enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *fanOutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	h2 := h.with(func(handler slog.Handler) slog.Handler { return handler.WithAttrs(attrs) })
+	if !h.grouped {
+		h2.attrs = append(h.attrs[:len(h.attrs):len(h.attrs)], attrs...)
+	}
+	return h2
+}
+
+func (h *fanOutHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	h2 := h.with(func(handler slog.Handler) slog.Handler { return handler.WithGroup(name) })
+	h2.grouped = true
+	return h2
+}
+
+func (h *fanOutHandler) with(f func(slog.Handler) slog.Handler) *fanOutHandler {
+	h2 := *h
+	h2.routes = make([]Route, len(h.routes))
+	for i, route := range h.routes {
+		route.Handler = f(route.Handler)
+		h2.routes[i] = route
+	}
+	return &h2
+}
+
+func (h *fanOutHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	var probe *slog.Record
+
+	for i := range h.routes {
+		route := &h.routes[i]
+		if !route.enabled(ctx, r.Level) {
+			continue
+		}
+
+		if route.Match != nil {
+			if probe == nil {
+				probe = h.probe(r)
+			}
+
+			if !route.Match(ctx, *probe) {
+				continue
+			}
+		}
+
+		if err := route.Handler.Handle(ctx, r.Clone()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// probe is the record observed by predicates, it has top-level attributes
+// only: attributes of the record and attributes defined via WithAttrs.
+// Attributes of the record are nested if the group is defined.
+func (h *fanOutHandler) probe(r slog.Record) *slog.Record {
+	switch {
+	case h.grouped:
+		c := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+		c.AddAttrs(h.attrs...)
+		return &c
+	case len(h.attrs) != 0:
+		c := r.Clone()
+		c.AddAttrs(h.attrs...)
+		return &c
+	default:
+		return &r
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// MatchLevel selects records with level within [from, to]
+func MatchLevel(from, to slog.Level) Predicate {
+	return func(_ context.Context, r slog.Record) bool {
+		return r.Level >= from && r.Level <= to
+	}
+}
+
+// MatchMod selects records logged by modules, the module is matched by
+// prefix of the source code path (see WithLogLevelForMod).
+//
+//	logger.MatchMod("github.com/fogfish/logger", "github.com/you/application")
+func MatchMod(mods ...string) Predicate {
+	root := trie.New()
+	for _, mod := range mods {
+		root.Append(mod, 0)
+	}
+	cache := newPCCache()
+
+	return func(_ context.Context, r slog.Record) bool {
+		if r.PC == 0 {
+			return false
+		}
+
+		e := cache.get(r.PC)
+		if e == nil {
+			fs := runtime.CallersFrames([]uintptr{r.PC})
+			f, _ := fs.Next()
+
+			_, n := root.Lookup(modPath(f.File, f.Function))
+			e = &pcEntry{pc: r.PC, rule: n.Rule}
+			cache.put(e)
+		}
+
+		return e.rule
+	}
+}
+
+// MatchAttr selects records having top-level attribute with the value,
+// attributes defined via WithAttrs are matched as well.
+//
+//	logger.MatchAttr("audit", true)
+func MatchAttr(key string, value any) Predicate {
+	expect := slog.AnyValue(value)
+
+	return func(_ context.Context, r slog.Record) bool {
+		has := false
+		r.Attrs(func(a slog.Attr) bool {
+			has = a.Key == key && a.Value.Resolve().Equal(expect)
+			return !has
+		})
+		return has
+	}
+}
@@ -0,0 +1,144 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+type failingHandler struct{ slog.Handler }
+
+func (failingHandler) Handle(context.Context, slog.Record) error { return errors.New("failed") }
+
+func TestFanOutHandler(t *testing.T) {
+	console, json, alerts := &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}
+	log := slog.New(
+		NewFanOutHandler(
+			Route{Handler: NewStdioHandler(WithWriter(console), WithoutSource())},
+			Route{Handler: NewJSONHandler(WithWriter(json), WithLogLevel(DEBUG))},
+			Route{Handler: NewJSONHandler(WithWriter(alerts)), Level: ERROR},
+		),
+	)
+
+	reset := func() { console.Reset(); json.Reset(); alerts.Reset() }
+
+	t.Run("Route", func(t *testing.T) {
+		defer reset()
+
+		log.Info("test")
+		if console.Len() == 0 || json.Len() == 0 || alerts.Len() != 0 {
+			t.Errorf("unexpected routing %q %q %q", console, json, alerts)
+		}
+	})
+
+	t.Run("Level", func(t *testing.T) {
+		defer reset()
+
+		log.Error("test")
+		if console.Len() == 0 || json.Len() == 0 || alerts.Len() == 0 {
+			t.Errorf("unexpected routing %q %q %q", console, json, alerts)
+		}
+	})
+
+	t.Run("Enabled", func(t *testing.T) {
+		defer reset()
+
+		if !log.Enabled(context.Background(), DEBUG) {
+			t.Errorf("DEBUG is enabled by json destination")
+		}
+
+		log.Debug("test")
+		if console.Len() != 0 || json.Len() == 0 || alerts.Len() != 0 {
+			t.Errorf("unexpected routing %q %q %q", console, json, alerts)
+		}
+	})
+
+	t.Run("WithAttrs", func(t *testing.T) {
+		defer reset()
+
+		log.With("a", 1).WithGroup("g").Error("test", "b", 2)
+		for _, b := range []*bytes.Buffer{json, alerts} {
+			if !strings.Contains(b.String(), `"a":1,"g":{"b":2}`) {
+				t.Errorf("unexpected log line %s", b)
+			}
+		}
+		if !strings.Contains(console.String(), "\"g\": {\n    \"b\": 2") {
+			t.Errorf("unexpected log line %s", console)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		h := NewFanOutHandler(
+			Route{Handler: failingHandler{NewJSONHandler(WithWriter(json))}},
+			Route{Handler: failingHandler{NewJSONHandler(WithWriter(json))}},
+		)
+
+		err := h.Handle(context.Background(), slog.NewRecord(time.Now(), INFO, "test", 0))
+		if err == nil || strings.Count(err.Error(), "failed") != 2 {
+			t.Errorf("unexpected error %v", err)
+		}
+	})
+}
+
+func TestFanOutPredicate(t *testing.T) {
+	b := &bytes.Buffer{}
+
+	route := func(match Predicate) *slog.Logger {
+		b.Reset()
+		return slog.New(
+			NewFanOutHandler(
+				Route{Handler: NewJSONHandler(WithWriter(b), WithLogLevel(DEBUG)), Match: match},
+			),
+		)
+	}
+
+	t.Run("MatchLevel", func(t *testing.T) {
+		log := route(MatchLevel(NOTICE, WARN))
+
+		log.Info("info")
+		log.Log(context.Background(), NOTICE, "notice")
+		log.Error("error")
+		if txt := b.String(); strings.Contains(txt, "info") || !strings.Contains(txt, "notice") || strings.Contains(txt, `"error"`) {
+			t.Errorf("unexpected log line %s", txt)
+		}
+	})
+
+	t.Run("MatchMod", func(t *testing.T) {
+		route(MatchMod("github.com/fogfish/logger/v3/fanout_test.go")).Info("test")
+		if b.Len() == 0 {
+			t.Errorf("record is not matched")
+		}
+
+		route(MatchMod("github.com/you/application")).Info("test")
+		if b.Len() != 0 {
+			t.Errorf("unexpected log line %s", b)
+		}
+	})
+
+	t.Run("MatchAttr", func(t *testing.T) {
+		log := route(MatchAttr("audit", true))
+
+		log.Info("a", "audit", false)
+		log.Info("b", "audit", true)
+		log.With("audit", true).Info("c")
+		log.WithGroup("g").Info("d", "audit", true)
+
+		txt := b.String()
+		if strings.Contains(txt, `"msg":"a"`) || !strings.Contains(txt, `"msg":"b"`) || !strings.Contains(txt, `"msg":"c"`) ||
+			strings.Contains(txt, `"msg":"d"`) {
+			t.Errorf("unexpected log line %s", txt)
+		}
+	})
+}
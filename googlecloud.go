@@ -0,0 +1,177 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// Google Cloud Logging structured JSON handler, it emits special fields
+// recognized by the logging agent:
+//
+//	{
+//	  "severity": "NOTICE",
+//	  "message": "...",
+//	  "timestamp": "2025-01-01T12:00:00.123456789Z",
+//	  "logging.googleapis.com/sourceLocation": {"file": "...", "line": "12", "function": "..."},
+//	  "logging.googleapis.com/trace": "projects/{project}/traces/{trace}",
+//	  "logging.googleapis.com/spanId": "...",
+//	  ...
+//	}
+//
+// The trace is taken from the context (see ContextWithTrace), the project is
+// configured with WithGoogleCloudProject or env GOOGLE_CLOUD_PROJECT.
+// Top-level attributes colliding with special fields are skipped.
+func NewGoogleCloudHandler(opts ...Option) slog.Handler {
+	config := defaultOpts(GoogleCloud...)
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	if config.project == "" {
+		config.project = os.Getenv("GOOGLE_CLOUD_PROJECT")
+	}
+
+	h := &googleCloudHandler{
+		w:       config.writer,
+		m:       &sync.Mutex{},
+		level:   config.level,
+		source:  config.addSource,
+		attrs:   config.attributes,
+		project: config.project,
+	}
+
+	return newHandlerChain(h, config)
+}
+
+type googleCloudHandler struct {
+	w       io.Writer
+	m       *sync.Mutex
+	level   slog.Leveler
+	source  bool
+	attrs   Attributes
+	goas    []groupOrAttrs
+	project string
+}
+
+func (h *googleCloudHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *googleCloudHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.goas = appendGroupOrAttrs(h.goas, groupOrAttrs{attrs: attrs})
+	return &h2
+}
+
+func (h *googleCloudHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.goas = appendGroupOrAttrs(h.goas, groupOrAttrs{group: name})
+	return &h2
+}
+
+func (h *googleCloudHandler) Handle(ctx context.Context, r slog.Record) error {
+	buf := newBuffer()
+	defer buf.free()
+
+	enc := jsonEncoder{buf: append(*buf, '{'), compact: true, special: googleCloudSpecialKeys}
+
+	enc.appendKey("severity")
+	enc.appendValue(slog.StringValue(googleCloudSeverity(r.Level)))
+
+	enc.appendKey("message")
+	enc.appendValue(slog.StringValue(r.Message))
+
+	if !r.Time.IsZero() {
+		enc.appendKey("timestamp")
+		enc.appendValue(slog.TimeValue(r.Time))
+	}
+
+	if h.source && r.PC != 0 {
+		a := h.attrs.handle(nil, slog.Any(slog.SourceKey, sourceOf(r.PC)))
+		if src, ok := a.Value.Any().(*slog.Source); ok && src != nil {
+			enc.appendGroup("logging.googleapis.com/sourceLocation", func() {
+				enc.appendKey("file")
+				enc.appendValue(slog.StringValue(src.File))
+				enc.appendKey("line")
+				enc.appendValue(slog.StringValue(strconv.Itoa(src.Line)))
+				enc.appendKey("function")
+				enc.appendValue(slog.StringValue(src.Function))
+			})
+		}
+	}
+
+	if trace, ok := TraceFromContext(ctx); ok {
+		enc.appendKey("logging.googleapis.com/trace")
+		if h.project != "" {
+			enc.appendValue(slog.StringValue("projects/" + h.project + "/traces/" + trace.TraceID))
+		} else {
+			enc.appendValue(slog.StringValue(trace.TraceID))
+		}
+
+		if trace.SpanID != "" {
+			enc.appendKey("logging.googleapis.com/spanId")
+			enc.appendValue(slog.StringValue(trace.SpanID))
+		}
+
+		enc.appendKey("logging.googleapis.com/trace_sampled")
+		enc.appendValue(slog.BoolValue(trace.Sampled))
+	}
+
+	enc.appendRecordAttrs(h.attrs, h.goas, r)
+	*buf = append(enc.buf, '}', '\n')
+
+	h.m.Lock()
+	defer h.m.Unlock()
+	_, err := h.w.Write(*buf)
+	return err
+}
+
+// The special fields of Google Cloud Logging, top-level attributes with
+// the same key are skipped so that they do not override fields of the record.
+var googleCloudSpecialKeys = []string{
+	"severity",
+	"message",
+	"timestamp",
+	"logging.googleapis.com/sourceLocation",
+	"logging.googleapis.com/trace",
+	"logging.googleapis.com/spanId",
+	"logging.googleapis.com/trace_sampled",
+}
+
+// severity of Google Cloud Logging
+func googleCloudSeverity(level slog.Level) string {
+	switch {
+	case level >= EMERGENCY:
+		return "EMERGENCY"
+	case level >= CRITICAL:
+		return "CRITICAL"
+	case level >= ERROR:
+		return "ERROR"
+	case level >= WARN:
+		return "WARNING"
+	case level >= NOTICE:
+		return "NOTICE"
+	case level >= INFO:
+		return "INFO"
+	default:
+		return "DEBUG"
+	}
+}
@@ -0,0 +1,106 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGoogleCloudSeverity(t *testing.T) {
+	for level, expected := range map[slog.Level]string{
+		EMERGENCY: "EMERGENCY",
+		CRITICAL:  "CRITICAL",
+		ERROR:     "ERROR",
+		WARN:      "WARNING",
+		NOTICE:    "NOTICE",
+		INFO:      "INFO",
+		DEBUG:     "DEBUG",
+	} {
+		if v := googleCloudSeverity(level); v != expected {
+			t.Errorf("unexpected severity %s of %s", v, level)
+		}
+	}
+}
+
+func TestGoogleCloudHandler(t *testing.T) {
+	b := &bytes.Buffer{}
+	log := slog.New(NewGoogleCloudHandler(WithWriter(b), WithGoogleCloudProject("test")))
+
+	t.Run("Record", func(t *testing.T) {
+		defer b.Reset()
+
+		log.Log(context.Background(), NOTICE, "test", "a", 1)
+
+		var val map[string]any
+		if err := json.Unmarshal(b.Bytes(), &val); err != nil {
+			t.Fatalf("invalid json %s", b.String())
+		}
+
+		if val["severity"] != "NOTICE" || val["message"] != "test" || val["a"] != 1.0 {
+			t.Errorf("unexpected log line %s", b.String())
+		}
+
+		if _, err := time.Parse(time.RFC3339Nano, val["timestamp"].(string)); err != nil {
+			t.Errorf("unexpected timestamp %s", b.String())
+		}
+
+		src, ok := val["logging.googleapis.com/sourceLocation"].(map[string]any)
+		if !ok || !strings.HasSuffix(src["file"].(string), "googlecloud_test.go") || src["line"] == "" {
+			t.Errorf("unexpected source location %s", b.String())
+		}
+	})
+
+	t.Run("SpecialKeys", func(t *testing.T) {
+		defer b.Reset()
+
+		log.With("message", "w").Info("test", "severity", "x", slog.Group("", "timestamp", "y"), slog.Group("g", "severity", "z"))
+
+		txt := b.String()
+		if strings.Count(txt, `"severity"`) != 2 ||
+			!strings.Contains(txt, `"severity":"INFO"`) ||
+			strings.Count(txt, `"message"`) != 1 ||
+			strings.Count(txt, `"timestamp"`) != 1 ||
+			!strings.Contains(txt, `"g":{"severity":"z"}`) {
+			t.Errorf("unexpected log line %s", txt)
+		}
+	})
+
+	t.Run("Trace", func(t *testing.T) {
+		defer b.Reset()
+
+		ctx := ContextWithTrace(context.Background(), Trace{TraceID: "abc", SpanID: "def", Sampled: true})
+		log.WithGroup("g").InfoContext(ctx, "test", "a", 1)
+
+		txt := b.String()
+		if !strings.Contains(txt, `"logging.googleapis.com/trace":"projects/test/traces/abc"`) ||
+			!strings.Contains(txt, `"logging.googleapis.com/spanId":"def"`) ||
+			!strings.Contains(txt, `"logging.googleapis.com/trace_sampled":true`) ||
+			!strings.Contains(txt, `"g":{"a":1}`) {
+			t.Errorf("unexpected log line %s", txt)
+		}
+	})
+
+	t.Run("ModRules", func(t *testing.T) {
+		defer b.Reset()
+
+		log := slog.New(NewGoogleCloudHandler(WithWriter(b),
+			WithLogLevelForMod(map[string]slog.Level{testMod(): ERROR}),
+		))
+		log.Warn("test")
+		if b.Len() != 0 {
+			t.Errorf("unexpected log line %s", b.String())
+		}
+	})
+}
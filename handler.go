@@ -9,17 +9,12 @@
 package logger
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"fmt"
 	"io"
 	"log/slog"
 	"runtime"
-	"strings"
 	"sync"
-
-	"github.com/fogfish/logger/v3/internal/trie"
+	"sync/atomic"
 )
 
 // JSON logger handler
@@ -37,14 +32,57 @@ func NewJSONHandler(opts ...Option) slog.Handler {
 		},
 	)
 
-	if config.trie == nil {
-		return h
+	return newHandlerChain(h, config)
+}
+
+// Logfmt logger handler, it outputs records as key=value pairs, groups are
+// flattened with dotted keys.
+//
+//	time=2025-01-01T12:00:00.000+02:00 level=INF source=gthb.fgfs.lggr/logger.go:12 msg="test" obj.key=val
+func NewLogfmtHandler(opts ...Option) slog.Handler {
+	config := defaultOpts(Logfmt...)
+	for _, opt := range opts {
+		opt(config)
 	}
 
-	return &modTrieHandler{
-		Handler: h,
-		trie:    config.trie,
+	h := slog.NewTextHandler(config.writer,
+		&slog.HandlerOptions{
+			AddSource:   config.addSource,
+			Level:       config.level,
+			ReplaceAttr: config.attributes.handle,
+		},
+	)
+
+	return newHandlerChain(h, config)
+}
+
+// Elastic Common Schema (ECS) JSON logger handler, it reshapes records into
+// ECS fields so that it is ingested without ingest pipeline:
+//
+//	{
+//	  "@timestamp": "2025-01-01T12:00:00.000+02:00",
+//	  "log.level": "info",
+//	  "log.origin": {"file": {"name": "...", "line": 12}, "function": "..."},
+//	  "message": "...",
+//	  "ecs.version": "8.11.0",
+//	  "error": {"message": "...", "type": "...", "stack_trace": "..."}
+//	}
+func NewECSHandler(opts ...Option) slog.Handler {
+	config := defaultOpts(ECS...)
+	for _, opt := range opts {
+		opt(config)
 	}
+
+	attrs := append(config.attributes[:len(config.attributes):len(config.attributes)], attrECS...)
+	h := slog.NewJSONHandler(config.writer,
+		&slog.HandlerOptions{
+			AddSource:   config.addSource,
+			Level:       config.level,
+			ReplaceAttr: attrs.handle,
+		},
+	).WithAttrs([]slog.Attr{slog.String("ecs.version", ecsVersion)})
+
+	return newHandlerChain(h, config)
 }
 
 //------------------------------------------------------------------------------
@@ -52,41 +90,113 @@ func NewJSONHandler(opts ...Option) slog.Handler {
 // The handler perform module-based logging
 type modTrieHandler struct {
 	slog.Handler
-	trie *trie.Node
+	rules *atomic.Pointer[modRules]
 }
 
-func (h *modTrieHandler) Enabled(context.Context, slog.Level) bool { return true }
+// newHandlerChain wraps the handler with features configured by options:
+// Lambda invocation context, sampling, module rules and flight recorder.
+func newHandlerChain(h slog.Handler, config *opts) slog.Handler {
+	if config.lambda {
+		h = newLambdaHandler(h, config.requestID)
+	}
+
+	// records replayed by flight recorder are not sampled
+	root := h
+	if config.sampling != nil {
+		h = newSamplingHandler(h, config.sampling)
+	}
+
+	if config.recorderSize > 0 {
+		return newRecorderHandler(h, root, newControlRules(h, config), config.recorderSize, config.recorderKey)
+	}
+
+	if config.mods == nil && config.control == nil && !config.signals {
+		return h
+	}
+
+	return newModTrieHandler(h, config)
+}
+
+// newModTrieHandler wraps the handler with module rules
+func newModTrieHandler(h slog.Handler, config *opts) slog.Handler {
+	return &modTrieHandler{Handler: h, rules: newControlRules(h, config)}
+}
+
+// newControlRules builds module rules attached to the control, rules are
+// changed at runtime via control or signals.
+func newControlRules(h slog.Handler, config *opts) *atomic.Pointer[modRules] {
+	rules := newModRules(config.level, config.mods)
+	if config.control == nil {
+		config.control = &Control{}
+	}
+	config.control.rules.Store(rules)
+
+	if config.signals {
+		config.control.signals.Do(func() { config.control.watchSignals(h, rules) })
+	}
+
+	return &config.control.rules
+}
+
+// Enabled reports whether any of module rules or fallback level accepts the
+// level, it allows slog to skip construction of records that are discarded
+// by every rule.
+func (h *modTrieHandler) Enabled(_ context.Context, level slog.Level) bool {
+	rules := h.rules.Load()
+	return level >= rules.min || level >= rules.fallback.Level()
+}
+
+func (h *modTrieHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &modTrieHandler{Handler: h.Handler.WithAttrs(attrs), rules: h.rules}
+}
+
+func (h *modTrieHandler) WithGroup(name string) slog.Handler {
+	return &modTrieHandler{Handler: h.Handler.WithGroup(name), rules: h.rules}
+}
 
 func (h *modTrieHandler) Handle(ctx context.Context, r slog.Record) error {
-	if r.PC == 0 {
-		return h.Handler.Handle(ctx, r)
+	if r.Level < h.rules.Load().levelOf(r.PC) {
+		return nil
 	}
 
-	fs := runtime.CallersFrames([]uintptr{r.PC})
-	f, _ := fs.Next()
+	return h.Handler.Handle(ctx, r)
+}
 
-	parts := strings.Split(f.File, "go/src/")
-	path := parts[0]
-	if len(parts) > 1 {
-		path = parts[1]
+// levelOf resolves the level of module rule for the call-site
+func (rules *modRules) levelOf(pc uintptr) slog.Level {
+	if pc == 0 {
+		return rules.fallback.Level()
 	}
 
-	_, n := h.trie.Lookup(path)
+	e := rules.cache.get(pc)
+	if e == nil {
+		fs := runtime.CallersFrames([]uintptr{pc})
+		f, _ := fs.Next()
 
-	if len(n.Path) != 0 && n.Level <= r.Level {
-		return h.Handler.Handle(ctx, r)
+		_, n := rules.trie.Lookup(modPath(f.File, f.Function))
+		e = &pcEntry{pc: pc, level: n.Level, rule: n.Rule}
+		rules.cache.put(e)
 	}
 
-	return nil
+	if !e.rule {
+		return rules.fallback.Level()
+	}
+
+	return e.level
 }
 
 //------------------------------------------------------------------------------
 
+// The handler outputs colored text line for humans, attributes of the record
+// are rendered as indented JSON object.
 type stdioHandler struct {
-	w io.Writer
-	h slog.Handler
-	b *bytes.Buffer
-	m sync.Mutex
+	w      io.Writer
+	m      *sync.Mutex
+	level  slog.Leveler
+	source bool
+	attrs  Attributes
+	pinned []string
+	goas   []groupOrAttrs
 }
 
 // Standard I/O handler
@@ -96,90 +206,105 @@ func NewStdioHandler(opts ...Option) slog.Handler {
 		opt(config)
 	}
 
-	b := &bytes.Buffer{}
-	h := slog.NewJSONHandler(b,
-		&slog.HandlerOptions{
-			AddSource:   config.addSource,
-			Level:       config.level,
-			ReplaceAttr: config.attributes.handle,
-		},
-	)
-
-	if config.trie == nil {
-		return &stdioHandler{w: config.writer, b: b, h: h}
+	h := &stdioHandler{
+		w:      config.writer,
+		m:      &sync.Mutex{},
+		level:  config.level,
+		source: config.addSource,
+		attrs:  config.attributes,
+		pinned: config.pinned,
 	}
 
-	return &stdioHandler{w: config.writer, b: b,
-		h: &modTrieHandler{
-			Handler: h,
-			trie:    config.trie,
-		},
-	}
+	return newHandlerChain(h, config)
 }
 
 func (h *stdioHandler) Enabled(ctx context.Context, level slog.Level) bool {
-	return h.h.Enabled(ctx, level)
+	return level >= h.level.Level()
 }
 
 func (h *stdioHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	return &stdioHandler{h: h.h.WithAttrs(attrs), b: h.b}
+	if len(attrs) == 0 {
+		return h
+	}
+	return h.withGroupOrAttrs(groupOrAttrs{attrs: attrs})
 }
 
 func (h *stdioHandler) WithGroup(name string) slog.Handler {
-	return &stdioHandler{h: h.h.WithGroup(name), b: h.b}
+	if name == "" {
+		return h
+	}
+	return h.withGroupOrAttrs(groupOrAttrs{group: name})
+}
+
+func (h *stdioHandler) withGroupOrAttrs(goa groupOrAttrs) *stdioHandler {
+	h2 := *h
+	h2.goas = appendGroupOrAttrs(h.goas, goa)
+	return &h2
 }
 
 func (h *stdioHandler) Handle(ctx context.Context, r slog.Record) error {
-	attrs, err := h.computeAttrs(ctx, r)
-	if err != nil {
-		return err
+	buf := newBuffer()
+	defer buf.free()
+
+	if !r.Time.IsZero() {
+		if a := h.attrs.handle(nil, slog.Time(slog.TimeKey, r.Time)); a.Key != "" {
+			*buf = appendText(*buf, a.Value.Resolve())
+			*buf = append(*buf, ' ')
+		}
 	}
 
-	time := attrs["time"]
+	if a := h.attrs.handle(nil, slog.Any(slog.LevelKey, r.Level)); a.Key != "" {
+		*buf = appendText(*buf, a.Value.Resolve())
+		*buf = append(*buf, ' ')
+	}
 
-	level := attrs["level"]
-	cl := levelColorForText[r.Level]
-	msg := cl + r.Message + colorReset
+	if a := h.attrs.handle(nil, slog.String(slog.MessageKey, r.Message)); a.Key != "" {
+		*buf = append(*buf, levelColorForText[r.Level]...)
+		*buf = appendText(*buf, a.Value.Resolve())
+		*buf = append(*buf, colorReset...)
+	}
 
-	delete(attrs, "level")
-	delete(attrs, "time")
-	delete(attrs, "msg")
+	at := len(*buf)
+	*buf = append(*buf, ' ')
+	*buf = append(*buf, levelColorForAttr[r.Level]...)
+	*buf = append(*buf, '{')
 
-	if len(attrs) == 0 {
-		fmt.Fprintln(h.w, time, level, msg)
-		return nil
+	var source slog.Attr
+	if h.source && r.PC != 0 {
+		source = slog.Any(slog.SourceKey, sourceOf(r.PC))
 	}
 
-	bytes, err := json.MarshalIndent(attrs, "", "  ")
-	if err != nil {
-		return err
+	enc := jsonEncoder{buf: *buf, depth: 1}
+	if len(h.pinned) != 0 {
+		enc.appendPinnedAttrs(h.attrs, h.pinned, h.goas, r, source)
 	}
+	enc.appendRecordAttrs(h.attrs, h.goas, r)
+	if source.Key != "" {
+		enc.appendAttr(h.attrs, source)
+	}
+	*buf = enc.buf
 
-	ca := levelColorForAttr[r.Level]
-	obj := ca + string(bytes) + colorReset
-
-	fmt.Fprintln(h.w, time, level, msg, obj)
-	return nil
-}
+	if enc.n == 0 {
+		*buf = (*buf)[:at]
+	} else {
+		*buf = append(*buf, '\n', '}')
+		*buf = append(*buf, colorReset...)
+	}
+	*buf = append(*buf, '\n')
 
-func (h *stdioHandler) computeAttrs(
-	ctx context.Context,
-	r slog.Record,
-) (map[string]any, error) {
 	h.m.Lock()
-	defer func() {
-		h.b.Reset()
-		h.m.Unlock()
-	}()
-
-	if err := h.h.Handle(ctx, r); err != nil {
-		return nil, err
-	}
+	defer h.m.Unlock()
+	_, err := h.w.Write(*buf)
+	return err
+}
 
-	var attrs map[string]any
-	err := json.Unmarshal(h.b.Bytes(), &attrs)
-	if err != nil {
-		return nil, err
+// sourceOf resolves the source code location of the program counter
+func sourceOf(pc uintptr) *slog.Source {
+	fs := runtime.CallersFrames([]uintptr{pc})
+	f, _ := fs.Next()
+	return &slog.Source{
+		Function: f.Function,
+		File:     f.File,
+		Line:     f.Line,
 	}
-	return attrs, nil
 }
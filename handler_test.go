@@ -0,0 +1,234 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// module of the test file as it is seen by module rules
+func testMod() string {
+	pc, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(modPath(file, runtime.FuncForPC(pc).Name()))
+}
+
+func TestModTrieHandler(t *testing.T) {
+	for name, handler := range map[string]func(...Option) slog.Handler{
+		"JSON":  NewJSONHandler,
+		"Stdio": NewStdioHandler,
+	} {
+		t.Run(name, func(t *testing.T) {
+			b := &bytes.Buffer{}
+			log := slog.New(
+				handler(
+					WithWriter(b),
+					WithLogLevel(DEBUG),
+					WithLogLevelForMod(map[string]slog.Level{testMod(): WARN}),
+				),
+			)
+
+			for name, log := range map[string]*slog.Logger{
+				"Logger":    log,
+				"WithAttrs": log.With("a", 1),
+				"WithGroup": log.WithGroup("g"),
+				"Nested":    log.With("a", 1).WithGroup("g").With("b", 2),
+			} {
+				t.Run(name, func(t *testing.T) {
+					defer b.Reset()
+
+					log.Info("test")
+					if b.Len() != 0 {
+						t.Errorf("unexpected log line %s", b.String())
+					}
+
+					log.Warn("test")
+					if b.Len() == 0 {
+						t.Errorf("expected log line")
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestModTrieHandlerFallback(t *testing.T) {
+	b := &bytes.Buffer{}
+
+	itShouldLog := func(t *testing.T, log *slog.Logger, level slog.Level, expected bool) {
+		t.Helper()
+		defer b.Reset()
+
+		log.Log(context.Background(), level, "test")
+		if (b.Len() != 0) != expected {
+			t.Errorf("unexpected output at %s: %s", level, b.String())
+		}
+	}
+
+	t.Run("Global", func(t *testing.T) {
+		log := slog.New(NewJSONHandler(WithWriter(b), WithLogLevel(WARN),
+			WithLogLevelForMod(map[string]slog.Level{"github.com/some/other": DEBUG}),
+		))
+
+		itShouldLog(t, log, INFO, false)
+		itShouldLog(t, log, WARN, true)
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		log := slog.New(NewJSONHandler(WithWriter(b), WithLogLevel(INFO),
+			WithLogLevelForMod(map[string]slog.Level{"github.com/some/other": DEBUG, "*": ERROR}),
+		))
+
+		itShouldLog(t, log, WARN, false)
+		itShouldLog(t, log, ERROR, true)
+	})
+
+	t.Run("DefaultFromEnv", func(t *testing.T) {
+		t.Setenv("CONFIG_LOG_LEVEL_DEFAULT", "ERROR")
+		log := slog.New(NewJSONHandler(WithWriter(b), WithLogLevel(INFO), WithLogLevelForModFromEnv()))
+
+		itShouldLog(t, log, WARN, false)
+		itShouldLog(t, log, ERROR, true)
+	})
+
+	t.Run("Rule", func(t *testing.T) {
+		log := slog.New(NewJSONHandler(WithWriter(b), WithLogLevel(INFO),
+			WithLogLevelForMod(map[string]slog.Level{testMod(): DEBUG, "*": ERROR}),
+		))
+
+		itShouldLog(t, log, DEBUG, true)
+	})
+
+	t.Run("Intermediate", func(t *testing.T) {
+		// rules share common prefix with the module, it is not the match
+		mod := testMod()
+		log := slog.New(NewJSONHandler(WithWriter(b), WithLogLevel(WARN),
+			WithLogLevelForMod(map[string]slog.Level{
+				filepath.Dir(mod) + "/zz-none-a": DEBUG,
+				filepath.Dir(mod) + "/zz-none-b": DEBUG,
+			}),
+		))
+
+		itShouldLog(t, log, INFO, false)
+		itShouldLog(t, log, WARN, true)
+	})
+}
+
+func TestModTrieHandlerEnabled(t *testing.T) {
+	h := NewJSONHandler(WithWriter(io.Discard), WithLogLevel(WARN),
+		WithLogLevelForMod(map[string]slog.Level{
+			"github.com/some/module": INFO,
+			"github.com/some/other":  ERROR,
+		}),
+	)
+
+	for level, expected := range map[slog.Level]bool{
+		DEBUG:  false,
+		INFO:   true,
+		NOTICE: true,
+		WARN:   true,
+		ERROR:  true,
+	} {
+		if h.Enabled(context.Background(), level) != expected {
+			t.Errorf("unexpected Enabled(%s)", level)
+		}
+	}
+}
+
+// emulates handler that discovers module rule for every record
+type alwaysEnabled struct{ slog.Handler }
+
+func (alwaysEnabled) Enabled(context.Context, slog.Level) bool { return true }
+
+func BenchmarkModTrieHandler(b *testing.B) {
+	h := NewJSONHandler(WithWriter(io.Discard), WithLogLevel(INFO),
+		WithLogLevelForMod(map[string]slog.Level{
+			"github.com/fogfish/logger":   INFO,
+			"github.com/aws/aws-sdk-go":   WARN,
+			"github.com/you/application":  INFO,
+			"github.com/you/dependencies": ERROR,
+		}),
+	)
+
+	for name, log := range map[string]*slog.Logger{
+		"AlwaysEnabled": slog.New(alwaysEnabled{h}),
+		"Enabled":       slog.New(h),
+	} {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				log.Debug("test", "key", "val", slog.Group("obj", "a", 1, "b", true))
+			}
+		})
+	}
+}
+
+func TestModTrieHandlerCache(t *testing.T) {
+	h := NewJSONHandler(WithWriter(io.Discard), WithLogLevel(WARN),
+		WithLogLevelForMod(map[string]slog.Level{testMod(): DEBUG}),
+	).(*modTrieHandler)
+	rules := h.rules.Load()
+
+	var pcs [1]uintptr
+	runtime.Callers(1, pcs[:])
+
+	for i := 0; i < 2; i++ {
+		if lvl := rules.levelOf(pcs[0]); lvl != DEBUG {
+			t.Errorf("unexpected level %s", lvl)
+		}
+
+		if e := rules.cache.get(pcs[0]); e == nil || e.level != DEBUG || !e.rule {
+			t.Errorf("unexpected cache entry %v", e)
+		}
+	}
+
+	if lvl := rules.levelOf(0); lvl != WARN {
+		t.Errorf("unexpected level %s", lvl)
+	}
+}
+
+func BenchmarkModTrieHandlerCache(b *testing.B) {
+	h := NewJSONHandler(WithWriter(io.Discard), WithLogLevel(INFO),
+		WithLogLevelForMod(map[string]slog.Level{
+			"github.com/fogfish/logger":   INFO,
+			"github.com/aws/aws-sdk-go":   WARN,
+			"github.com/you/application":  INFO,
+			"github.com/you/dependencies": ERROR,
+			testMod():                     WARN,
+		}),
+	).(*modTrieHandler)
+
+	var pcs [1]uintptr
+	runtime.Callers(1, pcs[:])
+	r := slog.NewRecord(time.Now(), INFO, "test", pcs[0])
+
+	cold := *h.rules.Load()
+	cold.cache = nil
+	coldh := &modTrieHandler{Handler: h.Handler, rules: &atomic.Pointer[modRules]{}}
+	coldh.rules.Store(&cold)
+
+	for name, h := range map[string]*modTrieHandler{
+		"Cold": coldh,
+		"Warm": h,
+	} {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				h.Handle(context.Background(), r)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ type Node struct {
 	Path  string  // substring from the path "owned" by the node
 	Heir  []*Node // heir nodes
 	Level slog.Level
+	Rule  bool // node defines log level, otherwise it is intermediate one
 }
 
 // New creates new trie
@@ -30,15 +31,19 @@ func New() *Node {
 	return root
 }
 
-// lookup is hot-path discovery of node at the path
+// lookup is hot-path discovery of node at the path, it returns the deepest
+// node that defines log level (rule). The root node is returned if none of
+// rules matches the path.
 func (root *Node) Lookup(path string) (at int, node *Node) {
 	node = root
+	rule := root
+	ruleAt := 0
 lookup:
 	for {
 		// leaf node, no futher lookup is possible
-		// return current `node`` and position `at` path
+		// return last rule and position `at` path
 		if len(node.Heir) == 0 {
-			return
+			return ruleAt, rule
 		}
 
 		for _, heir := range node.Heir {
@@ -49,9 +54,7 @@ lookup:
 
 			// the node consumers entire path
 			if len(heir.Path) == 1 && heir.Path[0] == '*' {
-				at = len(path)
-				node = heir
-				return
+				return len(path), heir
 			}
 
 			if path[at] != heir.Path[0] {
@@ -64,28 +67,32 @@ lookup:
 				// node matches the path, continue lookup
 				at = at + len(heir.Path)
 				node = heir
+				if node.Rule {
+					rule, ruleAt = node, at
+				}
 				continue lookup
 			}
 		}
 
-		return
+		return ruleAt, rule
 	}
 }
 
 func (root *Node) Append(path string, level slog.Level) {
 	if strings.HasSuffix(path, "*") {
-		node := root.append(path[:len(path)-1], 0)
-		node.Heir = append(node.Heir, &Node{Path: "*", Heir: make([]*Node, 0), Level: level})
+		node := root.append(path[:len(path)-1])
+		node.Heir = append(node.Heir, &Node{Path: "*", Heir: make([]*Node, 0), Level: level, Rule: true})
 		return
 	}
 
-	root.append(path, level)
+	node := root.append(path)
+	node.Level = level
+	node.Rule = true
 }
 
-func (root *Node) append(path string, level slog.Level) *Node {
+func (root *Node) append(path string) *Node {
 	if len(path) == 0 {
-		_, n := root.appendTo("/")
-		n.Level = level
+		return root
 	}
 
 	at, node := root.appendTo(path)
@@ -100,7 +107,6 @@ func (root *Node) append(path string, level slog.Level) *Node {
 		node = split
 	}
 
-	node.Level = level
 	return node
 }
 
@@ -0,0 +1,231 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"context"
+	"encoding/binary"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// JournaldHandler emits records through systemd-journald native protocol.
+type JournaldHandler struct {
+	slog.Handler
+	conn *journaldConn
+}
+
+// The default socket of systemd-journald native protocol
+const journaldSocket = "/run/systemd/journal/socket"
+
+// systemd-journald logger handler, it writes records into the socket
+// (default /run/systemd/journal/socket if empty).
+//
+//	h := logger.NewJournaldHandler("")
+//	defer h.Close()
+//
+// The 7 log levels are mapped one-to-one to PRIORITY, the source is emitted
+// as CODE_FILE, CODE_LINE and CODE_FUNC. Attributes are emitted as upper-cased
+// journal fields, groups are flattened with "_" (e.g. REQUEST_ID). Entries
+// exceeding the datagram size are passed as file descriptor.
+func NewJournaldHandler(socket string, opts ...Option) *JournaldHandler {
+	config := defaultOpts(Journald...)
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	if socket == "" {
+		socket = journaldSocket
+	}
+
+	if config.appName == "" {
+		config.appName = filepath.Base(os.Args[0])
+	}
+
+	conn := &journaldConn{addr: &net.UnixAddr{Name: socket, Net: "unixgram"}}
+	h := &journaldHandler{
+		conn:    conn,
+		level:   config.level,
+		source:  config.addSource,
+		attrs:   config.attributes,
+		appName: config.appName,
+	}
+
+	return &JournaldHandler{
+		Handler: newHandlerChain(h, config),
+		conn:    conn,
+	}
+}
+
+// Close connection to journald
+func (h *JournaldHandler) Close() error {
+	return h.conn.Close()
+}
+
+//------------------------------------------------------------------------------
+
+type journaldHandler struct {
+	conn    *journaldConn
+	level   slog.Leveler
+	source  bool
+	attrs   Attributes
+	goas    []groupOrAttrs
+	appName string
+}
+
+func (h *journaldHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *journaldHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.goas = appendGroupOrAttrs(h.goas, groupOrAttrs{attrs: attrs})
+	return &h2
+}
+
+func (h *journaldHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.goas = appendGroupOrAttrs(h.goas, groupOrAttrs{group: name})
+	return &h2
+}
+
+func (h *journaldHandler) Handle(ctx context.Context, r slog.Record) error {
+	buf := newBuffer()
+	defer buf.free()
+
+	*buf = appendJournaldField(*buf, "MESSAGE", r.Message)
+	*buf = appendJournaldField(*buf, "PRIORITY", strconv.Itoa(syslogSeverity(r.Level)))
+	*buf = appendJournaldField(*buf, "SYSLOG_IDENTIFIER", h.appName)
+
+	if h.source && r.PC != 0 {
+		a := h.attrs.handle(nil, slog.Any(slog.SourceKey, sourceOf(r.PC)))
+		if src, ok := a.Value.Any().(*slog.Source); ok && src != nil {
+			*buf = appendJournaldField(*buf, "CODE_FILE", src.File)
+			*buf = appendJournaldField(*buf, "CODE_LINE", strconv.Itoa(src.Line))
+			*buf = appendJournaldField(*buf, "CODE_FUNC", src.Function)
+		}
+	}
+
+	walkFlatAttrs(h.attrs, h.goas, r, func(key string, v slog.Value) {
+		*buf = appendJournaldField(*buf, journaldFieldName(key), string(appendText(nil, v)))
+	})
+
+	return h.conn.Write(*buf)
+}
+
+// The fields written by the handler, attributes are prefixed with "X_" so that
+// they do not conflict with fields of the record.
+var journaldSpecialFields = map[string]bool{
+	"MESSAGE":           true,
+	"PRIORITY":          true,
+	"SYSLOG_IDENTIFIER": true,
+	"CODE_FILE":         true,
+	"CODE_LINE":         true,
+	"CODE_FUNC":         true,
+}
+
+// Field names are upper-cased letters, digits and "_", up to 64 bytes.
+// The leading "_" is reserved for trusted fields, it is removed.
+func journaldFieldName(key string) string {
+	b := make([]byte, 0, len(key))
+	for i := 0; i < len(key) && len(b) < 64; i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+			b = append(b, c-'a'+'A')
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			b = append(b, c)
+		case len(b) > 0:
+			b = append(b, '_')
+		}
+	}
+
+	if len(b) == 0 || (b[0] >= '0' && b[0] <= '9') || journaldSpecialFields[string(b)] {
+		b = append([]byte("X_"), b...)
+	}
+
+	return string(b)
+}
+
+// KEY=value\n or KEY\n<little-endian uint64 size>value\n for multi-line values
+func appendJournaldField(buf []byte, key, val string) []byte {
+	buf = append(buf, key...)
+
+	for i := 0; i < len(val); i++ {
+		if val[i] == '\n' {
+			buf = append(buf, '\n')
+			buf = binary.LittleEndian.AppendUint64(buf, uint64(len(val)))
+			buf = append(buf, val...)
+			return append(buf, '\n')
+		}
+	}
+
+	buf = append(buf, '=')
+	buf = append(buf, val...)
+	return append(buf, '\n')
+}
+
+//------------------------------------------------------------------------------
+
+// journaldConn is unixgram socket, it is re-established on failures
+type journaldConn struct {
+	sync.Mutex
+	addr *net.UnixAddr
+	conn *net.UnixConn
+}
+
+func (c *journaldConn) Write(msg []byte) error {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.conn == nil {
+		conn, err := net.DialUnix("unixgram", nil, c.addr)
+		if err != nil {
+			return err
+		}
+		c.conn = conn
+	}
+
+	_, err := c.conn.Write(msg)
+	if err == nil {
+		return nil
+	}
+
+	// the entry exceeds the datagram size, it is passed as file descriptor
+	if journaldTooLarge(err) {
+		return journaldWriteFd(c.conn, msg)
+	}
+
+	c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+func (c *journaldConn) Close() error {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.conn == nil {
+		return nil
+	}
+
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
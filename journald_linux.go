@@ -0,0 +1,100 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+//go:build linux
+
+package logger
+
+import (
+	"errors"
+	"net"
+	"os"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// journaldTooLarge returns true if the entry exceeds the datagram size
+func journaldTooLarge(err error) bool {
+	return errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS)
+}
+
+// journaldWriteFd passes the entry as file descriptor of sealed memfd. The
+// unlinked file at /dev/shm is used if memfd is not supported by the kernel,
+// journald accepts both.
+func journaldWriteFd(conn *net.UnixConn, msg []byte) error {
+	f, err := journaldMemfd()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(msg); err != nil {
+		return err
+	}
+
+	// seal memfd, journald requires sealed memfd; no-op for regular files
+	syscall.Syscall(syscall.SYS_FCNTL, f.Fd(), fAddSeals, fSealAll)
+
+	// WriteMsgUnix is not allowed for connected datagram socket
+	rc, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	werr := rc.Write(func(fd uintptr) bool {
+		err = syscall.Sendmsg(int(fd), nil, syscall.UnixRights(int(f.Fd())), nil, 0)
+		return err != syscall.EAGAIN
+	})
+	if werr != nil {
+		return werr
+	}
+
+	return err
+}
+
+const (
+	fAddSeals = 1024 + 9 // F_ADD_SEALS
+	fSealAll  = 0x1 | 0x2 | 0x4 | 0x8
+	mfdSeal   = 0x2 // MFD_ALLOW_SEALING
+	mfdExec   = 0x1 // MFD_CLOEXEC
+)
+
+// memfd_create is not defined by syscall package for all architectures
+var sysMemfdCreate = map[string]uintptr{
+	"386":     356,
+	"amd64":   319,
+	"arm":     385,
+	"arm64":   279,
+	"loong64": 279,
+	"ppc64le": 360,
+	"riscv64": 279,
+	"s390x":   350,
+}[runtime.GOARCH]
+
+func journaldMemfd() (*os.File, error) {
+	if sysMemfdCreate != 0 {
+		name := []byte("journal\x00")
+		fd, _, errno := syscall.Syscall(sysMemfdCreate, uintptr(unsafe.Pointer(&name[0])), mfdExec|mfdSeal, 0)
+		if errno == 0 {
+			return os.NewFile(fd, "journal"), nil
+		}
+	}
+
+	f, err := os.CreateTemp("/dev/shm", "journal.*")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Remove(f.Name()); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return f, nil
+}
@@ -0,0 +1,157 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+//go:build linux
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestJournaldFieldName(t *testing.T) {
+	for key, expected := range map[string]string{
+		"request_id": "REQUEST_ID",
+		"g.a":        "G_A",
+		"_trusted":   "TRUSTED",
+		"1st":        "X_1ST",
+		"":           "X_",
+		"Ünicode":    "NICODE",
+		"message":    "X_MESSAGE",
+		"priority":   "X_PRIORITY",
+		"code_file":  "X_CODE_FILE",
+		"message_id": "MESSAGE_ID",
+	} {
+		if v := journaldFieldName(key); v != expected {
+			t.Errorf("unexpected field name %s of %s", v, key)
+		}
+	}
+}
+
+func TestJournaldField(t *testing.T) {
+	if v := string(appendJournaldField(nil, "A", "b")); v != "A=b\n" {
+		t.Errorf("unexpected field %q", v)
+	}
+
+	v := appendJournaldField(nil, "A", "b\nc")
+	size := make([]byte, 8)
+	binary.LittleEndian.PutUint64(size, 3)
+	if !bytes.Equal(v, append(append([]byte("A\n"), size...), "b\nc\n"...)) {
+		t.Errorf("unexpected field %q", v)
+	}
+}
+
+func TestJournaldHandler(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "journal.socket")
+	l, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	h := NewJournaldHandler(socket, WithSyslogAppName("test"))
+	defer h.Close()
+	log := slog.New(h)
+
+	recv := func(t *testing.T) string {
+		t.Helper()
+
+		b, oob := make([]byte, 64*1024), make([]byte, 1024)
+		l.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, oobn, _, _, err := l.ReadMsgUnix(b, oob)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if oobn == 0 {
+			return string(b[:n])
+		}
+
+		msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+		if err != nil {
+			t.Fatal(err)
+		}
+		fds, err := syscall.ParseUnixRights(&msgs[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		f := os.NewFile(uintptr(fds[0]), "journal")
+		defer f.Close()
+
+		data, err := io.ReadAll(io.NewSectionReader(f, 0, 1<<30))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	t.Run("SpecialFields", func(t *testing.T) {
+		log.Warn("test", "message", "x", "syslog_identifier", "y")
+
+		txt := recv(t)
+		if strings.Count(txt, "\nMESSAGE=") != 0 || !strings.HasPrefix(txt, "MESSAGE=test\n") ||
+			strings.Count(txt, "SYSLOG_IDENTIFIER=") != 2 ||
+			!strings.Contains(txt, "X_MESSAGE=x\n") ||
+			!strings.Contains(txt, "X_SYSLOG_IDENTIFIER=y\n") {
+			t.Errorf("unexpected fields %q", txt)
+		}
+	})
+
+	t.Run("Record", func(t *testing.T) {
+		log.With("request_id", "x").WithGroup("g").Warn("test", "a", 1)
+
+		txt := recv(t)
+		for _, field := range []string{
+			"MESSAGE=test\n",
+			"PRIORITY=4\n",
+			"SYSLOG_IDENTIFIER=test\n",
+			"CODE_LINE=",
+			"CODE_FUNC=github.com/fogfish/logger/v3.TestJournaldHandler",
+			"REQUEST_ID=x\n",
+			"G_A=1\n",
+		} {
+			if !strings.Contains(txt, field) {
+				t.Errorf("field %q is not found at %q", field, txt)
+			}
+		}
+
+		if !strings.Contains(txt, "CODE_FILE=") || !strings.Contains(txt, "journald_linux_test.go") {
+			t.Errorf("unexpected source %q", txt)
+		}
+	})
+
+	t.Run("Priority", func(t *testing.T) {
+		log.Log(context.Background(), EMERGENCY, "test")
+
+		if txt := recv(t); !strings.Contains(txt, "PRIORITY=0\n") {
+			t.Errorf("unexpected priority %q", txt)
+		}
+	})
+
+	t.Run("LargeEntry", func(t *testing.T) {
+		large := strings.Repeat("x", 1<<20)
+		log.Info("test", "large", large)
+
+		txt := recv(t)
+		if !strings.Contains(txt, "MESSAGE=test\n") || !strings.Contains(txt, "LARGE="+large+"\n") {
+			t.Errorf("unexpected large entry of %d bytes", len(txt))
+		}
+	})
+}
@@ -0,0 +1,26 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+//go:build !linux
+
+package logger
+
+import (
+	"errors"
+	"net"
+)
+
+// journald is available at linux only
+func journaldTooLarge(err error) bool {
+	return false
+}
+
+// journald is available at linux only
+func journaldWriteFd(conn *net.UnixConn, msg []byte) error {
+	return errors.New("journald entry is too large")
+}
@@ -0,0 +1,162 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// The key of X-Ray trace header used by Lambda Go runtime at the context
+const lambdaTraceKey = "x-amzn-trace-id"
+
+// lambdaHandler enriches records with the invocation context of AWS Lambda.
+// The invocation attributes are top-level attributes of the record, groups
+// and attributes defined via WithGroup, WithAttrs are re-applied on top of
+// them if needed.
+type lambdaHandler struct {
+	slog.Handler
+	root       slog.Handler
+	goas       []groupOrAttrs
+	requestID  func(context.Context) string
+	coldStart  *atomic.Pointer[string]
+	invocation *atomic.Pointer[lambdaInvocation]
+}
+
+// lambdaInvocation is the handler derived for the invocation, it is cached
+// so that groups and attributes are not re-applied for each record.
+type lambdaInvocation struct {
+	key     [3]slog.Attr
+	handler slog.Handler
+}
+
+func newLambdaHandler(h slog.Handler, requestID func(context.Context) string) slog.Handler {
+	var attrs []slog.Attr
+	if name := os.Getenv("AWS_LAMBDA_FUNCTION_NAME"); name != "" {
+		attrs = append(attrs, slog.String("function_name", name))
+	}
+	if version := os.Getenv("AWS_LAMBDA_FUNCTION_VERSION"); version != "" {
+		attrs = append(attrs, slog.String("function_version", version))
+	}
+	if memory, err := strconv.Atoi(os.Getenv("AWS_LAMBDA_FUNCTION_MEMORY_SIZE")); err == nil {
+		attrs = append(attrs, slog.Int("function_memory_size", memory))
+	}
+
+	if len(attrs) != 0 {
+		h = h.WithAttrs(attrs)
+	}
+
+	return &lambdaHandler{
+		Handler:    h,
+		root:       h,
+		requestID:  requestID,
+		coldStart:  &atomic.Pointer[string]{},
+		invocation: &atomic.Pointer[lambdaInvocation]{},
+	}
+}
+
+func (h *lambdaHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return h.withGroupOrAttrs(groupOrAttrs{attrs: attrs}, h.Handler.WithAttrs(attrs))
+}
+
+func (h *lambdaHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.withGroupOrAttrs(groupOrAttrs{group: name}, h.Handler.WithGroup(name))
+}
+
+func (h *lambdaHandler) withGroupOrAttrs(goa groupOrAttrs, handler slog.Handler) *lambdaHandler {
+	h2 := *h
+	h2.Handler = handler
+	h2.goas = appendGroupOrAttrs(h.goas, goa)
+	h2.invocation = &atomic.Pointer[lambdaInvocation]{}
+	return &h2
+}
+
+func (h *lambdaHandler) Handle(ctx context.Context, r slog.Record) error {
+	var attrs [3]slog.Attr
+	n := 0
+
+	if h.requestID != nil {
+		if id := h.requestID(ctx); id != "" {
+			// the first invocation observed by the process is cold start
+			h.coldStart.CompareAndSwap(nil, &id)
+			attrs[n] = slog.String("requestId", id)
+			attrs[n+1] = slog.Bool("cold_start", *h.coldStart.Load() == id)
+			n += 2
+		}
+	}
+
+	if trace := lambdaTraceID(ctx); trace != "" {
+		attrs[n] = slog.String("xray_trace_id", trace)
+		n++
+	}
+
+	if n == 0 {
+		return h.Handler.Handle(ctx, r)
+	}
+
+	grouped := false
+	for _, goa := range h.goas {
+		grouped = grouped || goa.group != ""
+	}
+
+	if !grouped {
+		r = r.Clone()
+		r.AddAttrs(attrs[:n]...)
+		return h.Handler.Handle(ctx, r)
+	}
+
+	inv := h.invocation.Load()
+	if inv == nil || !lambdaSameAttrs(inv.key, attrs) {
+		inv = &lambdaInvocation{
+			key:     attrs,
+			handler: applyGroupOrAttrs(h.root.WithAttrs(attrs[:n]), h.goas),
+		}
+		h.invocation.Store(inv)
+	}
+
+	return inv.handler.Handle(ctx, r)
+}
+
+func lambdaSameAttrs(a, b [3]slog.Attr) bool {
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// lambdaTraceID returns X-Ray trace id, the trace header is taken from
+// the context (Lambda Go runtime) or env _X_AMZN_TRACE_ID:
+//
+//	Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1
+func lambdaTraceID(ctx context.Context) string {
+	header, _ := ctx.Value(lambdaTraceKey).(string)
+	if header == "" {
+		header = os.Getenv("_X_AMZN_TRACE_ID")
+	}
+
+	for _, kv := range strings.Split(header, ";") {
+		if root, has := strings.CutPrefix(kv, "Root="); has {
+			return root
+		}
+	}
+
+	return ""
+}
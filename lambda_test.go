@@ -0,0 +1,210 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+)
+
+type requestIDKey struct{}
+
+func requestIDOf(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func TestLambdaTraceID(t *testing.T) {
+	header := "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1"
+
+	t.Run("Context", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), lambdaTraceKey, header)
+		if v := lambdaTraceID(ctx); v != "1-5759e988-bd862e3fe1be46a994272793" {
+			t.Errorf("unexpected trace id %s", v)
+		}
+	})
+
+	t.Run("Env", func(t *testing.T) {
+		t.Setenv("_X_AMZN_TRACE_ID", header)
+		if v := lambdaTraceID(context.Background()); v != "1-5759e988-bd862e3fe1be46a994272793" {
+			t.Errorf("unexpected trace id %s", v)
+		}
+	})
+
+	t.Run("None", func(t *testing.T) {
+		if v := lambdaTraceID(context.Background()); v != "" {
+			t.Errorf("unexpected trace id %s", v)
+		}
+	})
+}
+
+func TestLambdaContext(t *testing.T) {
+	t.Setenv("AWS_LAMBDA_FUNCTION_NAME", "test")
+	t.Setenv("AWS_LAMBDA_FUNCTION_VERSION", "$LATEST")
+	t.Setenv("AWS_LAMBDA_FUNCTION_MEMORY_SIZE", "128")
+	t.Setenv("_X_AMZN_TRACE_ID", "Root=1-abc;Sampled=1")
+
+	b := &bytes.Buffer{}
+	log := slog.New(NewJSONHandler(WithWriter(b), WithLambdaContext(requestIDOf)))
+
+	decode := func(t *testing.T) map[string]any {
+		t.Helper()
+
+		var val map[string]any
+		if err := json.Unmarshal(b.Bytes(), &val); err != nil {
+			t.Fatalf("invalid json %s", b.String())
+		}
+		return val
+	}
+
+	t.Run("ColdStart", func(t *testing.T) {
+		defer b.Reset()
+
+		ctx := context.WithValue(context.Background(), requestIDKey{}, "a")
+		log.InfoContext(ctx, "test")
+		val := decode(t)
+
+		if val["requestId"] != "a" || val["cold_start"] != true ||
+			val["function_name"] != "test" ||
+			val["function_version"] != "$LATEST" ||
+			val["function_memory_size"] != 128.0 ||
+			val["xray_trace_id"] != "1-abc" {
+			t.Errorf("unexpected log line %s", b.String())
+		}
+	})
+
+	t.Run("WarmStart", func(t *testing.T) {
+		defer b.Reset()
+
+		ctx := context.WithValue(context.Background(), requestIDKey{}, "b")
+		log.InfoContext(ctx, "test")
+		val := decode(t)
+
+		if val["requestId"] != "b" || val["cold_start"] != false {
+			t.Errorf("unexpected log line %s", b.String())
+		}
+	})
+
+	t.Run("NoRequest", func(t *testing.T) {
+		defer b.Reset()
+
+		log.Info("test")
+		val := decode(t)
+
+		if _, has := val["requestId"]; has || val["function_name"] != "test" {
+			t.Errorf("unexpected log line %s", b.String())
+		}
+	})
+
+	t.Run("Group", func(t *testing.T) {
+		defer b.Reset()
+
+		ctx := context.WithValue(context.Background(), requestIDKey{}, "c")
+		log.With("a", 1).WithGroup("g").InfoContext(ctx, "test", "b", 2)
+		val := decode(t)
+
+		g, ok := val["g"].(map[string]any)
+		if val["requestId"] != "c" || val["a"] != 1.0 || !ok || g["b"] != 2.0 || g["requestId"] != nil {
+			t.Errorf("unexpected log line %s", b.String())
+		}
+	})
+
+	t.Run("GroupInvocation", func(t *testing.T) {
+		grouped := log.WithGroup("g")
+		h := grouped.Handler().(*lambdaHandler)
+
+		seq := []*lambdaInvocation{}
+		for _, id := range []string{"d", "d", "e"} {
+			b.Reset()
+			ctx := context.WithValue(context.Background(), requestIDKey{}, id)
+			grouped.InfoContext(ctx, "test", "b", 2)
+
+			val := decode(t)
+			g, _ := val["g"].(map[string]any)
+			if val["requestId"] != id || g["b"] != 2.0 {
+				t.Errorf("unexpected log line %s", b.String())
+			}
+			seq = append(seq, h.invocation.Load())
+		}
+
+		// the derived handler is re-used within the invocation
+		if seq[0] != seq[1] || seq[1] == seq[2] {
+			t.Errorf("derived handler is not cached per invocation")
+		}
+	})
+}
+
+// setenv defines env variable for the test, empty value undefines it
+func setenv(t *testing.T, key, val string) {
+	t.Helper()
+
+	t.Setenv(key, val)
+	if val == "" {
+		os.Unsetenv(key)
+	}
+}
+
+func TestLambdaLogLevel(t *testing.T) {
+	for _, tt := range []struct {
+		config, lambda string
+		expected       slog.Level
+	}{
+		{"", "", INFO},
+		{"", "TRACE", DEBUG},
+		{"", "DEBUG", DEBUG},
+		{"", "info", INFO},
+		{"", "WARN", WARN},
+		{"", "ERROR", ERROR},
+		{"", "FATAL", CRITICAL},
+		{"", "UNKNOWN", INFO},
+		{"NOTICE", "", NOTICE},
+		{"ERROR", "DEBUG", ERROR},
+		{"UNKNOWN", "WARN", WARN},
+	} {
+		t.Run(tt.config+"/"+tt.lambda, func(t *testing.T) {
+			setenv(t, "CONFIG_LOG_LEVEL", tt.config)
+			setenv(t, "AWS_LAMBDA_LOG_LEVEL", tt.lambda)
+
+			config := defaultOpts(CloudWatch...)
+			if config.level.Level() != tt.expected {
+				t.Errorf("unexpected level %s, expected %s", config.level, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLambdaLogFormat(t *testing.T) {
+	for _, tt := range []struct {
+		lambda, profile, format, expected string
+	}{
+		{"", "", "JSON", "INF" + colorReset},
+		{"test", "", "", `"level":"INFO"`},
+		{"test", "", "JSON", `"level":"INFO"`},
+		{"test", "", "Text", "level=INF"},
+		{"test", "GoogleCloud", "Text", `"severity":"INFO"`},
+		{"test", "Logfmt", "JSON", "level=INF"},
+	} {
+		t.Run(tt.lambda+"/"+tt.profile+"/"+tt.format, func(t *testing.T) {
+			setenv(t, "AWS_LAMBDA_FUNCTION_NAME", tt.lambda)
+			setenv(t, "CONFIG_LOG_PROFILE", tt.profile)
+			setenv(t, "AWS_LAMBDA_LOG_FORMAT", tt.format)
+
+			b := &bytes.Buffer{}
+			New(WithWriter(b)).Info("test")
+			if !strings.Contains(b.String(), tt.expected) {
+				t.Errorf("unexpected log line %s", b.String())
+			}
+		})
+	}
+}
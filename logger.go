@@ -12,26 +12,54 @@ package logger
 import (
 	"log/slog"
 	"os"
+	"strings"
 )
 
-// Create New Logger
+// Create New Logger, the output format is selected by env:
+//
+//	CONFIG_LOG_PROFILE     defines the format (CloudWatch, Logfmt, GoogleCloud, ECS or Console)
+//	AWS_LAMBDA_LOG_FORMAT  JSON or Text at AWS Lambda if the profile is not defined
+//
+// JSON is used at AWS Lambda (env AWS_LAMBDA_FUNCTION_NAME), the colored
+// console output is used otherwise.
 func New(opts ...Option) *slog.Logger {
-	if _, has := os.LookupEnv("AWS_LAMBDA_FUNCTION_NAME"); has {
-		return slog.New(NewJSONHandler(opts...))
-	}
-
 	if preset, has := os.LookupEnv("CONFIG_LOG_PROFILE"); has {
 		switch preset {
 		case "CloudWatch":
 			return slog.New(NewJSONHandler(opts...))
+		case "Logfmt":
+			return slog.New(NewLogfmtHandler(opts...))
+		case "GoogleCloud":
+			return slog.New(NewGoogleCloudHandler(opts...))
+		case "ECS":
+			return slog.New(NewECSHandler(opts...))
 		default:
 			return slog.New(NewStdioHandler(opts...))
 		}
 	}
 
+	if _, has := os.LookupEnv("AWS_LAMBDA_FUNCTION_NAME"); has {
+		if strings.EqualFold(os.Getenv("AWS_LAMBDA_LOG_FORMAT"), "Text") {
+			return slog.New(NewLogfmtHandler(opts...))
+		}
+		return slog.New(NewJSONHandler(opts...))
+	}
+
 	return slog.New(NewStdioHandler(opts...))
 }
 
+// Create New Logger together with control of log levels, the control
+// allows to change log levels at runtime.
+//
+//	log, ctl := logger.NewWithControl()
+//	slog.SetDefault(log)
+//	ctl.SetLevel(logger.DEBUG)
+func NewWithControl(opts ...Option) (*slog.Logger, *Control) {
+	ctl := NewControl()
+	opts = append(opts[:len(opts):len(opts)], WithControl(ctl))
+	return New(opts...), ctl
+}
+
 const (
 	// EMERGENCY
 	// system is unusable, panic execution of current routine/application,
@@ -138,4 +166,14 @@ var (
 		"INFO":      INFO,
 		"DEBUG":     DEBUG,
 	}
+
+	shortNames = map[string]slog.Level{
+		"EMR": EMERGENCY,
+		"CRT": CRITICAL,
+		"ERR": ERROR,
+		"WRN": WARN,
+		"NTC": NOTICE,
+		"INF": INFO,
+		"DEB": DEBUG,
+	}
 )
@@ -10,6 +10,8 @@ package logger
 
 import (
 	"bytes"
+	"context"
+	"io"
 	"log/slog"
 	"strings"
 	"testing"
@@ -128,3 +130,198 @@ func TestJSONLogger(t *testing.T) {
 		}
 	})
 }
+
+func TestStdioLoggerAttrs(t *testing.T) {
+	b := &bytes.Buffer{}
+	log := slog.New(NewStdioHandler(WithWriter(b), WithoutSource()))
+
+	t.Run("NoAttrs", func(t *testing.T) {
+		defer b.Reset()
+
+		log.Info("test")
+		txt := b.String()
+		if strings.Contains(txt, "{") || !strings.HasSuffix(txt, "test"+colorReset+"\n") {
+			t.Errorf("unexpected log line %s", txt)
+		}
+	})
+
+	t.Run("WithAttrs", func(t *testing.T) {
+		defer b.Reset()
+
+		log.With("a", 1).Info("test", "b", "x")
+		txt := b.String()
+		if !strings.Contains(txt, "{\n  \"b\": \"x\",\n  \"a\": 1\n}") {
+			t.Errorf("unexpected log line %s", txt)
+		}
+	})
+
+	t.Run("WithGroup", func(t *testing.T) {
+		defer b.Reset()
+
+		log.With("a", 1).WithGroup("g").Info("test", slog.Group("n", "b", true))
+		txt := b.String()
+		if !strings.Contains(txt, "{\n  \"a\": 1,\n  \"g\": {\n    \"n\": {\n      \"b\": true\n    }\n  }\n}") {
+			t.Errorf("unexpected log line %s", txt)
+		}
+	})
+
+	t.Run("EmptyGroup", func(t *testing.T) {
+		defer b.Reset()
+
+		log.WithGroup("g").Info("test", slog.Group("n"))
+		txt := b.String()
+		if strings.Contains(txt, "{") {
+			t.Errorf("unexpected log line %s", txt)
+		}
+	})
+
+	t.Run("Escape", func(t *testing.T) {
+		defer b.Reset()
+
+		log.Info("test", "s", "a\"b\n", "m", map[string]int{"x": 1})
+		txt := b.String()
+		if !strings.Contains(txt, `"s": "a\"b\n"`) ||
+			!strings.Contains(txt, "\"m\": {\n    \"x\": 1\n  }") {
+			t.Errorf("unexpected log line %s", txt)
+		}
+	})
+}
+
+func TestStdioLoggerAttrsOrder(t *testing.T) {
+	b := &bytes.Buffer{}
+
+	t.Run("CallSite", func(t *testing.T) {
+		log := slog.New(NewStdioHandler(WithWriter(b), WithoutSource()))
+		log.Info("test", "z", 1, "a", 2, "m", 3)
+
+		txt := b.String()
+		if !strings.Contains(txt, "{\n  \"z\": 1,\n  \"a\": 2,\n  \"m\": 3\n}") {
+			t.Errorf("unexpected log line %s", txt)
+		}
+	})
+
+	t.Run("Nesting", func(t *testing.T) {
+		defer b.Reset()
+
+		log := slog.New(NewStdioHandler(WithWriter(b), WithoutSource()))
+		log.With("a", 1).WithGroup("g").With("b", 2).Info("test", "c", 3)
+
+		txt := b.String()
+		if !strings.Contains(txt, "{\n  \"a\": 1,\n  \"g\": {\n    \"c\": 3,\n    \"b\": 2\n  }\n}") {
+			t.Errorf("unexpected log line %s", txt)
+		}
+	})
+
+	t.Run("Source", func(t *testing.T) {
+		defer b.Reset()
+
+		log := slog.New(NewStdioHandler(WithWriter(b)))
+		log.Info("test", "z", 1)
+
+		txt := b.String()
+		if strings.Index(txt, "\"source\"") < strings.Index(txt, "\"z\"") {
+			t.Errorf("unexpected log line %s", txt)
+		}
+	})
+
+	t.Run("Pinned", func(t *testing.T) {
+		defer b.Reset()
+
+		log := slog.New(NewStdioHandler(WithWriter(b), WithoutSource(), WithPinnedAttrs("err", "request_id")))
+		log.With("request_id", "x").Info("test", "z", 1, "err", "y")
+
+		txt := b.String()
+		if !strings.Contains(txt, "{\n  \"err\": \"y\",\n  \"request_id\": \"x\",\n  \"z\": 1\n}") {
+			t.Errorf("unexpected log line %s", txt)
+		}
+	})
+
+	t.Run("PinnedInlineGroup", func(t *testing.T) {
+		defer b.Reset()
+
+		log := slog.New(NewStdioHandler(WithWriter(b), WithoutSource(), WithPinnedAttrs("err")))
+		log.Info("test", slog.Group("", "err", "boom", "x", 1))
+		log.With(slog.Group("", "err", "w")).Info("test")
+
+		txt := b.String()
+		if !strings.Contains(txt, "{\n  \"err\": \"boom\",\n  \"x\": 1\n}") ||
+			!strings.Contains(txt, "{\n  \"err\": \"w\"\n}") {
+			t.Errorf("unexpected log line %s", txt)
+		}
+	})
+
+	t.Run("PinnedSource", func(t *testing.T) {
+		defer b.Reset()
+
+		log := slog.New(NewStdioHandler(WithWriter(b), WithSource(), WithPinnedAttrs("source")))
+		log.Info("test", "z", 1)
+
+		txt := b.String()
+		if strings.Count(txt, "\"source\"") != 1 || strings.Index(txt, "\"source\"") > strings.Index(txt, "\"z\"") {
+			t.Errorf("unexpected log line %s", txt)
+		}
+	})
+}
+
+func BenchmarkStdioLogger(b *testing.B) {
+	log := slog.New(NewStdioHandler(WithWriter(io.Discard)))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			log.Info("test", "key", "val", slog.Group("obj", "a", 1, "b", true))
+		}
+	})
+}
+
+func TestLogfmtLogger(t *testing.T) {
+	b := &bytes.Buffer{}
+	log := slog.New(NewLogfmtHandler(WithWriter(b), WithLogLevel(DEBUG)))
+
+	t.Run("Levels", func(t *testing.T) {
+		for level, name := range levelShortName {
+			b.Reset()
+
+			log.Log(context.Background(), level, "test")
+			txt := b.String()
+			if !strings.Contains(txt, "level="+name) ||
+				!strings.Contains(txt, "msg=test") ||
+				!strings.Contains(txt, "source=gthb.fgfs.lggr") {
+				t.Errorf("unexpected log line %s", txt)
+			}
+		}
+	})
+
+	t.Run("Attrs", func(t *testing.T) {
+		defer b.Reset()
+
+		log.With("a", 1).WithGroup("g").Info("test", "s", "x y", slog.Group("n", "q", `"`))
+		txt := b.String()
+		if !strings.Contains(txt, " a=1 ") ||
+			!strings.Contains(txt, ` g.s="x y"`) ||
+			!strings.Contains(txt, ` g.n.q="\""`) {
+			t.Errorf("unexpected log line %s", txt)
+		}
+	})
+}
+
+func TestNew(t *testing.T) {
+	for profile, expected := range map[string]string{
+		"CloudWatch":  `"level":"INFO"`,
+		"Logfmt":      "level=INF",
+		"GoogleCloud": `"severity":"INFO"`,
+		"ECS":         `"log.level":"info"`,
+		"Console":     "INF",
+	} {
+		t.Run(profile, func(t *testing.T) {
+			t.Setenv("CONFIG_LOG_PROFILE", profile)
+
+			b := &bytes.Buffer{}
+			New(WithWriter(b)).Info("test")
+			if !strings.Contains(b.String(), expected) {
+				t.Errorf("unexpected log line %s", b.String())
+			}
+		})
+	}
+}
@@ -9,12 +9,12 @@
 package logger
 
 import (
+	"context"
 	"io"
 	"log/slog"
 	"os"
 	"strings"
-
-	"github.com/fogfish/logger/v3/internal/trie"
+	"time"
 )
 
 var (
@@ -26,6 +26,7 @@ var (
 		WithLogLevelFromEnv(),
 		WithSourceShorten(),
 		WithLogLevelForModFromEnv(),
+		WithSamplingFromEnv(),
 	}
 
 	// Preset for CloudWatch logging
@@ -35,6 +36,63 @@ var (
 		WithSourceShorten(),
 		WithoutTimestamp(),
 		WithLogLevelForModFromEnv(),
+		WithSamplingFromEnv(),
+	}
+
+	// Preset for Google Cloud Logging
+	GoogleCloud = []Option{
+		WithLogLevel(INFO),
+		WithLogLevelFromEnv(),
+		WithSource(),
+		WithLogLevelForModFromEnv(),
+		WithSamplingFromEnv(),
+	}
+
+	// Preset for Elastic Common Schema (ECS) logging
+	ECS = []Option{
+		WithLogLevel(INFO),
+		WithLogLevelFromEnv(),
+		WithSource(),
+		WithLogLevelForModFromEnv(),
+		WithSamplingFromEnv(),
+	}
+
+	// Preset for OpenTelemetry logging
+	OTLP = []Option{
+		WithLogLevel(INFO),
+		WithLogLevelFromEnv(),
+		WithSource(),
+		WithLogLevelForModFromEnv(),
+		WithSamplingFromEnv(),
+	}
+
+	// Preset for systemd-journald logging
+	Journald = []Option{
+		WithLogLevel(INFO),
+		WithLogLevelFromEnv(),
+		WithSource(),
+		WithLogLevelForModFromEnv(),
+		WithSamplingFromEnv(),
+	}
+
+	// Preset for syslog logging
+	Syslog = []Option{
+		WithSyslogFacility(1),
+		WithLogLevel(INFO),
+		WithLogLevelFromEnv(),
+		WithSourceShorten(),
+		WithLogLevelForModFromEnv(),
+		WithSamplingFromEnv(),
+	}
+
+	// Preset for logfmt logging
+	Logfmt = []Option{
+		WithLogLevelShorten(),
+		WithLogLevel(INFO),
+		WithLogLevelFromEnv(),
+		WithSourceShorten(),
+		WithLogLevelForModFromEnv(),
+		WithSamplingFromEnv(),
 	}
 )
 
@@ -42,11 +100,29 @@ var (
 type Option func(*opts)
 
 type opts struct {
-	writer     io.Writer
-	level      slog.Leveler
-	attributes Attributes
-	addSource  bool
-	trie       *trie.Node
+	writer        io.Writer
+	level         slog.Leveler
+	attributes    Attributes
+	addSource     bool
+	pinned        []string
+	mods          map[string]slog.Level
+	control       *Control
+	signals       bool
+	facility      int
+	appName       string
+	msgID         string
+	project       string
+	resource      []slog.Attr
+	headers       map[string]string
+	batchSize     int
+	batchInterval time.Duration
+	retries       int
+	backoff       time.Duration
+	lambda        bool
+	requestID     func(context.Context) string
+	sampling      *sampling
+	recorderSize  int
+	recorderKey   func(context.Context) string
 }
 
 func defaultOpts(preset ...Option) *opts {
@@ -94,19 +170,38 @@ func WithLogLevel(level slog.Leveler) Option {
 // Config Log Level from env CONFIG_LOG_LEVEL, default INFO
 //
 //	export CONFIG_LOG_LEVEL=DEBUG
+//
+// The level of AWS Lambda advanced logging controls (env AWS_LAMBDA_LOG_LEVEL)
+// is used if CONFIG_LOG_LEVEL is not defined, Lambda levels are mapped as
+// TRACE, DEBUG to DEBUG; INFO to INFO; WARN to WARN; ERROR to ERROR and
+// FATAL to CRITICAL.
 func WithLogLevelFromEnv() Option {
 	return func(o *opts) {
-		level, defined := os.LookupEnv("CONFIG_LOG_LEVEL")
-		if !defined {
-			return
+		if level, defined := os.LookupEnv("CONFIG_LOG_LEVEL"); defined {
+			if lvl, has := longNames[level]; has {
+				o.level = lvl
+				return
+			}
 		}
 
-		if lvl, has := longNames[level]; has {
-			o.level = lvl
+		if level, defined := os.LookupEnv("AWS_LAMBDA_LOG_LEVEL"); defined {
+			if lvl, has := lambdaLevels[strings.ToUpper(level)]; has {
+				o.level = lvl
+			}
 		}
 	}
 }
 
+// The log levels of AWS Lambda advanced logging controls
+var lambdaLevels = map[string]slog.Level{
+	"TRACE": DEBUG,
+	"DEBUG": DEBUG,
+	"INFO":  INFO,
+	"WARN":  WARN,
+	"ERROR": ERROR,
+	"FATAL": CRITICAL,
+}
+
 // WithLevel7 enables from DEBUG to EMERGENCY levels
 func WithLogLevel7() Option {
 	return func(o *opts) {
@@ -128,6 +223,7 @@ func WithLogLevelShorten() Option {
 //	log.WithLogLevelForMod(map[string]slog.Level{
 //		"github.com/fogfish/logger": log.INFO,
 //		"github.com/you/application": log.DEBUG,
+//		"*": log.WARN,
 //	})
 //
 // The logger uses prefix matching to determine the appropriate log level based
@@ -141,11 +237,16 @@ func WithLogLevelShorten() Option {
 //
 // * Per User/Namespace: A log level defined at a higher level
 // (e.g., github.com/fogfish) applies to all modules under that namespace.
+//
+// The level of the record is defined by the most specific rule matching
+// the source code path. The default rule "*" applies to modules not covered
+// by any rule. The global log level (see WithLogLevel) is used if the default
+// rule is not defined.
 func WithLogLevelForMod(mods map[string]slog.Level) Option {
 	return func(o *opts) {
-		o.trie = trie.New()
+		o.mods = make(map[string]slog.Level, len(mods))
 		for mod, lvl := range mods {
-			o.trie.Append(mod, lvl)
+			o.mods[mod] = lvl
 		}
 	}
 }
@@ -156,6 +257,7 @@ func WithLogLevelForMod(mods map[string]slog.Level) Option {
 //
 //	export CONFIG_LOG_LEVEL_DEBUG=github.com/fogfish/logger:github.com/your/app
 //	export CONFIG_LOG_LEVEL_INFO=github.com/fogfish
+//	export CONFIG_LOG_LEVEL_DEFAULT=WARN
 //
 // * Per File: A log level defined for a specific file
 // (e.g., github.com/fogfish/logger/logger.go) applies only to that file.
@@ -165,18 +267,26 @@ func WithLogLevelForMod(mods map[string]slog.Level) Option {
 //
 // * Per User/Namespace: A log level defined at a higher level
 // (e.g., github.com/fogfish) applies to all modules under that namespace.
-
+//
+// The variable CONFIG_LOG_LEVEL_DEFAULT defines log level of modules
+// not covered by any rule, the global log level is used otherwise.
 func WithLogLevelForModFromEnv() Option {
 	return func(o *opts) {
-		root := trie.New()
+		mods := map[string]slog.Level{}
 		for lvl, name := range levelLongName {
 			for _, mod := range fromEnvMods("CONFIG_LOG_LEVEL_" + name) {
-				root.Append(mod, lvl)
+				mods[mod] = lvl
+			}
+		}
+
+		if level, defined := os.LookupEnv("CONFIG_LOG_LEVEL_DEFAULT"); defined {
+			if lvl, has := longNames[level]; has {
+				mods["*"] = lvl
 			}
 		}
 
-		if len(root.Heir) != 0 {
-			o.trie = root
+		if len(mods) != 0 {
+			o.mods = mods
 		}
 	}
 }
@@ -190,6 +300,191 @@ func fromEnvMods(key string) []string {
 	return strings.Split(value, ":")
 }
 
+// Config console output to write attributes with given keys first.
+// Console output preserves the order of attributes: attributes given at
+// call-site, attributes defined via WithAttrs, nested groups and the source.
+// Pinned keys are written ahead, useful for attributes like error or request
+// id that has to be visible at glance. The source is pinned by key "source".
+//
+//	log.WithPinnedAttrs("err", "request_id")
+func WithPinnedAttrs(keys ...string) Option {
+	return func(o *opts) {
+		o.pinned = append(o.pinned, keys...)
+	}
+}
+
+// Attach the control of log levels to the handler, the control is configured
+// with log level and module rules of the handler. It allows to change log
+// levels at runtime.
+func WithControl(ctl *Control) Option {
+	return func(o *opts) {
+		o.control = ctl
+	}
+}
+
+// Change log levels on signals, it is useful for processes without admin port
+// (see Control for details):
+//
+//	SIGUSR1 cycles the global level down through 7 levels (DEBUG is followed by EMERGENCY)
+//	SIGUSR2 resets log levels to initial configuration
+//	SIGHUP re-reads CONFIG_LOG_LEVEL and CONFIG_LOG_LEVEL_* variables
+//
+// The change is logged as NOTICE with configuration before and after.
+// Signals are not supported at Windows, the option has no effect.
+func WithSignalControl() Option {
+	return func(o *opts) {
+		o.signals = true
+	}
+}
+
+// Config syslog facility, default 1 (user-level messages)
+func WithSyslogFacility(facility int) Option {
+	return func(o *opts) {
+		o.facility = facility
+	}
+}
+
+// Config syslog APP-NAME (SYSLOG_IDENTIFIER of journald), default is the name
+// of executable
+func WithSyslogAppName(name string) Option {
+	return func(o *opts) {
+		o.appName = name
+	}
+}
+
+// Config syslog MSGID, default is nil value "-"
+func WithSyslogMsgID(msgID string) Option {
+	return func(o *opts) {
+		o.msgID = msgID
+	}
+}
+
+// Config Google Cloud project, it is used to correlate records with traces,
+// default is env GOOGLE_CLOUD_PROJECT
+func WithGoogleCloudProject(project string) Option {
+	return func(o *opts) {
+		o.project = project
+	}
+}
+
+// Enrich records with the invocation context of AWS Lambda: the request id,
+// cold start flag, function name, version and memory size (env
+// AWS_LAMBDA_FUNCTION_*) and X-Ray trace id (env _X_AMZN_TRACE_ID). The
+// request id is taken from the context by the given function, use
+// lambdacontext of Lambda Go runtime:
+//
+//	log.WithLambdaContext(func(ctx context.Context) string {
+//		if lc, ok := lambdacontext.FromContext(ctx); ok {
+//			return lc.AwsRequestID
+//		}
+//		return ""
+//	})
+//
+// Records have to be logged with the context of invocation (e.g. slog.InfoContext).
+func WithLambdaContext(requestID func(context.Context) string) Option {
+	return func(o *opts) {
+		o.lambda = true
+		o.requestID = requestID
+	}
+}
+
+// Config OpenTelemetry resource attributes, service.name is defined by
+// default (see NewOTLPHandler)
+func WithOTLPResource(attrs ...slog.Attr) Option {
+	return func(o *opts) {
+		o.resource = append(o.resource, attrs...)
+	}
+}
+
+// Config HTTP headers of OTLP export requests (e.g. authorization)
+func WithOTLPHeaders(headers map[string]string) Option {
+	return func(o *opts) {
+		o.headers = make(map[string]string, len(headers))
+		for key, val := range headers {
+			o.headers[key] = val
+		}
+	}
+}
+
+// Config batching of OTLP exporter, records are exported once batch of given
+// size is collected or periodically with given interval, default 512 records
+// and 5 seconds.
+func WithOTLPBatch(size int, interval time.Duration) Option {
+	return func(o *opts) {
+		o.batchSize = size
+		o.batchInterval = interval
+	}
+}
+
+// Config retries of OTLP exporter, the transient failures are retried with
+// exponential backoff, default no retries and 100ms.
+func WithOTLPRetry(retries int, backoff time.Duration) Option {
+	return func(o *opts) {
+		o.retries = retries
+		o.backoff = backoff
+	}
+}
+
+// Sample records of high-volume call-sites, the first records of call-site
+// (the message and source code location) within the window are logged, then
+// every Nth record is logged. WARN and above are never sampled. The number
+// of dropped records is reported by the next logged record of the call-site
+// as the attribute sampling_dropped.
+//
+//	log.WithSampling(100, 50, time.Second)
+func WithSampling(first, every int, window time.Duration) Option {
+	return func(o *opts) {
+		o.sampling = &sampling{first: first, every: every, window: window}
+	}
+}
+
+// Config sampling from env CONFIG_LOG_SAMPLE (see WithSampling), defaults
+// are first:100, every:100 and window:1s
+//
+//	export CONFIG_LOG_SAMPLE=first:100,every:50,window:1s
+func WithSamplingFromEnv() Option {
+	return func(o *opts) {
+		spec, defined := os.LookupEnv("CONFIG_LOG_SAMPLE")
+		if !defined {
+			return
+		}
+
+		if s, ok := parseSampling(spec); ok {
+			o.sampling = s
+		}
+	}
+}
+
+// Enable flight recorder, recent records discarded by log level (and module
+// rules) are kept in the ring buffer of given size. The buffer is logged
+// ahead of ERROR, CRITICAL or EMERGENCY record, replayed records have
+// attribute replayed=true. It allows to log at INFO but have DEBUG context
+// of failures.
+//
+//	log.WithFlightRecorder(100)
+func WithFlightRecorder(size int) Option {
+	return func(o *opts) {
+		o.recorderSize = size
+	}
+}
+
+// Config flight recorder to keep ring buffer per context key (e.g. request
+// id) instead of global one, the failure replays records of its key only.
+// Records logged without the key are kept in the global ring buffer. The ring
+// buffer of the key is released once the context is canceled (e.g. at the end
+// of HTTP request), the least recently used one is evicted if more than 1024
+// keys are tracked.
+//
+//	log.WithFlightRecorderKey(func(ctx context.Context) string {
+//		id, _ := ctx.Value(requestIDKey).(string)
+//		return id
+//	})
+func WithFlightRecorderKey(key func(context.Context) string) Option {
+	return func(o *opts) {
+		o.recorderKey = key
+	}
+}
+
 // Logs file name of the source file only
 func WithSourceFileName() Option {
 	return func(o *opts) {
@@ -0,0 +1,522 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OTLPHandler maps records into OpenTelemetry log data model and exports
+// them in batches over OTLP/HTTP JSON. Records are exported by background
+// routine either when batch is full or periodically. Flush and Shutdown
+// have to be called before the process exits.
+type OTLPHandler struct {
+	slog.Handler
+	exporter *otlpExporter
+}
+
+// The instrumentation scope of records
+const otlpScope = "github.com/fogfish/logger/v3"
+
+// ErrShutdown is returned if records are logged after the handler is shut down
+var ErrShutdown = errors.New("handler is shut down")
+
+// OpenTelemetry logger handler, it exports records to the collector endpoint
+// (e.g. http://localhost:4318/v1/logs). The endpoint is defined by env
+// OTEL_EXPORTER_OTLP_LOGS_ENDPOINT or OTEL_EXPORTER_OTLP_ENDPOINT if empty.
+//
+//	h := logger.NewOTLPHandler("http://localhost:4318/v1/logs",
+//		logger.WithOTLPResource(slog.String("service.version", "1.0.0")),
+//	)
+//	defer h.Shutdown(context.Background())
+//
+// The 7 log levels are mapped to severity numbers, the trace is taken from
+// the context (see ContextWithTrace), groups are flattened with dotted keys.
+// The resource attribute service.name is defined by env OTEL_SERVICE_NAME,
+// the name of executable is used otherwise.
+func NewOTLPHandler(endpoint string, opts ...Option) *OTLPHandler {
+	config := defaultOpts(OTLP...)
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	if endpoint == "" {
+		endpoint = otlpEndpointFromEnv()
+	}
+
+	if config.batchSize <= 0 {
+		config.batchSize = 512
+	}
+
+	if config.batchInterval <= 0 {
+		config.batchInterval = 5 * time.Second
+	}
+
+	if config.backoff <= 0 {
+		config.backoff = 100 * time.Millisecond
+	}
+
+	service := os.Getenv("OTEL_SERVICE_NAME")
+	if service == "" {
+		service = filepath.Base(os.Args[0])
+	}
+	resource := append([]slog.Attr{slog.String("service.name", service)}, config.resource...)
+
+	exporter := &otlpExporter{
+		endpoint:  endpoint,
+		headers:   config.headers,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		prefix:    otlpRequestPrefix(resource),
+		batchSize: config.batchSize,
+		maxQueue:  8 * config.batchSize,
+		retries:   config.retries,
+		backoff:   config.backoff,
+		kick:      make(chan struct{}, 1),
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+	go exporter.run(config.batchInterval)
+
+	h := &otlpHandler{
+		exporter: exporter,
+		level:    config.level,
+		source:   config.addSource,
+		attrs:    config.attributes,
+	}
+
+	return &OTLPHandler{
+		Handler:  newHandlerChain(h, config),
+		exporter: exporter,
+	}
+}
+
+// Flush exports all pending records
+func (h *OTLPHandler) Flush(ctx context.Context) error {
+	return h.exporter.export(ctx)
+}
+
+// Shutdown stops background export and flushes pending records, records
+// logged after shutdown are discarded.
+func (h *OTLPHandler) Shutdown(ctx context.Context) error {
+	return h.exporter.shutdown(ctx)
+}
+
+func otlpEndpointFromEnv() string {
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_LOGS_ENDPOINT"); endpoint != "" {
+		return endpoint
+	}
+
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); endpoint != "" {
+		return strings.TrimSuffix(endpoint, "/") + "/v1/logs"
+	}
+
+	return "http://localhost:4318/v1/logs"
+}
+
+//------------------------------------------------------------------------------
+
+type otlpHandler struct {
+	exporter *otlpExporter
+	level    slog.Leveler
+	source   bool
+	attrs    Attributes
+	goas     []groupOrAttrs
+}
+
+func (h *otlpHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *otlpHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.goas = appendGroupOrAttrs(h.goas, groupOrAttrs{attrs: attrs})
+	return &h2
+}
+
+func (h *otlpHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.goas = appendGroupOrAttrs(h.goas, groupOrAttrs{group: name})
+	return &h2
+}
+
+func (h *otlpHandler) Handle(ctx context.Context, r slog.Record) error {
+	buf := newBuffer()
+	defer buf.free()
+
+	*buf = append(*buf, `{"timeUnixNano":"`...)
+	if !r.Time.IsZero() {
+		*buf = strconv.AppendInt(*buf, r.Time.UnixNano(), 10)
+	} else {
+		*buf = append(*buf, '0')
+	}
+	*buf = append(*buf, `","observedTimeUnixNano":"`...)
+	*buf = strconv.AppendInt(*buf, time.Now().UnixNano(), 10)
+	*buf = append(*buf, `","severityNumber":`...)
+	*buf = strconv.AppendInt(*buf, int64(otlpSeverity(r.Level)), 10)
+	*buf = append(*buf, `,"severityText":`...)
+	*buf = appendJSONString(*buf, otlpSeverityText(r.Level))
+	*buf = append(*buf, `,"body":{"stringValue":`...)
+	*buf = appendJSONString(*buf, r.Message)
+	*buf = append(*buf, `},"attributes":[`...)
+
+	n := 0
+	attr := func(key string, v slog.Value) {
+		if n > 0 {
+			*buf = append(*buf, ',')
+		}
+		n++
+		*buf = appendOTLPKeyValue(*buf, key, v)
+	}
+
+	walkFlatAttrs(h.attrs, h.goas, r, attr)
+
+	if h.source && r.PC != 0 {
+		a := h.attrs.handle(nil, slog.Any(slog.SourceKey, sourceOf(r.PC)))
+		if src, ok := a.Value.Any().(*slog.Source); ok && src != nil {
+			attr("code.filepath", slog.StringValue(src.File))
+			attr("code.lineno", slog.IntValue(src.Line))
+			attr("code.function", slog.StringValue(src.Function))
+		}
+	}
+
+	*buf = append(*buf, ']')
+
+	if trace, ok := TraceFromContext(ctx); ok && isHex(trace.TraceID, 32) {
+		*buf = append(*buf, `,"traceId":"`...)
+		*buf = append(*buf, strings.ToLower(trace.TraceID)...)
+		*buf = append(*buf, '"')
+		if isHex(trace.SpanID, 16) {
+			*buf = append(*buf, `,"spanId":"`...)
+			*buf = append(*buf, strings.ToLower(trace.SpanID)...)
+			*buf = append(*buf, '"')
+		}
+		if trace.Sampled {
+			*buf = append(*buf, `,"flags":1`...)
+		}
+	}
+
+	*buf = append(*buf, '}')
+
+	return h.exporter.enqueue(append([]byte(nil), *buf...))
+}
+
+// OpenTelemetry severity number of the level
+func otlpSeverity(level slog.Level) int {
+	switch {
+	case level >= EMERGENCY:
+		return 24 // FATAL4
+	case level >= CRITICAL:
+		return 21 // FATAL
+	case level >= ERROR:
+		return 17 // ERROR
+	case level >= WARN:
+		return 13 // WARN
+	case level >= NOTICE:
+		return 10 // INFO2
+	case level >= INFO:
+		return 9 // INFO
+	default:
+		return 5 // DEBUG
+	}
+}
+
+func otlpSeverityText(level slog.Level) string {
+	if name, has := levelLongName[level]; has {
+		return name
+	}
+	return level.String()
+}
+
+func isHex(s string, size int) bool {
+	if len(s) != size {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// {"key": "...", "value": {"stringValue": "..."}}
+func appendOTLPKeyValue(buf []byte, key string, v slog.Value) []byte {
+	buf = append(buf, `{"key":`...)
+	buf = appendJSONString(buf, key)
+	buf = append(buf, `,"value":`...)
+	buf = appendOTLPValue(buf, v)
+	return append(buf, '}')
+}
+
+// AnyValue of OTLP/JSON, 64-bit integers are encoded as strings
+func appendOTLPValue(buf []byte, v slog.Value) []byte {
+	switch v.Kind() {
+	case slog.KindString:
+		buf = append(buf, `{"stringValue":`...)
+		buf = appendJSONString(buf, v.String())
+	case slog.KindInt64:
+		buf = append(buf, `{"intValue":"`...)
+		buf = strconv.AppendInt(buf, v.Int64(), 10)
+		buf = append(buf, '"')
+	case slog.KindUint64:
+		buf = append(buf, `{"intValue":"`...)
+		buf = strconv.AppendUint(buf, v.Uint64(), 10)
+		buf = append(buf, '"')
+	case slog.KindFloat64:
+		buf = append(buf, `{"doubleValue":`...)
+		f := v.Float64()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			buf = appendJSONString(buf, strconv.FormatFloat(f, 'g', -1, 64))
+		} else {
+			buf = strconv.AppendFloat(buf, f, 'g', -1, 64)
+		}
+	case slog.KindBool:
+		buf = append(buf, `{"boolValue":`...)
+		buf = strconv.AppendBool(buf, v.Bool())
+	case slog.KindDuration:
+		buf = append(buf, `{"intValue":"`...)
+		buf = strconv.AppendInt(buf, int64(v.Duration()), 10)
+		buf = append(buf, '"')
+	case slog.KindTime:
+		buf = append(buf, `{"stringValue":"`...)
+		buf = v.Time().AppendFormat(buf, time.RFC3339Nano)
+		buf = append(buf, '"')
+	default:
+		if b, ok := v.Any().([]byte); ok {
+			buf = append(buf, `{"bytesValue":"`...)
+			buf = append(buf, base64.StdEncoding.EncodeToString(b)...)
+			buf = append(buf, '"')
+		} else {
+			buf = append(buf, `{"stringValue":`...)
+			buf = appendJSONString(buf, v.String())
+		}
+	}
+
+	return append(buf, '}')
+}
+
+// ExportLogsServiceRequest up to the list of log records
+func otlpRequestPrefix(resource []slog.Attr) []byte {
+	buf := []byte(`{"resourceLogs":[{"resource":{"attributes":[`)
+	for i, a := range resource {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = appendOTLPKeyValue(buf, a.Key, a.Value.Resolve())
+	}
+	buf = append(buf, `]},"scopeLogs":[{"scope":{"name":"`+otlpScope+`"},"logRecords":[`...)
+	return buf
+}
+
+const otlpRequestSuffix = `]}]}]}`
+
+//------------------------------------------------------------------------------
+
+// otlpExporter queues encoded log records and exports them in batches
+type otlpExporter struct {
+	endpoint  string
+	headers   map[string]string
+	client    *http.Client
+	prefix    []byte
+	batchSize int
+	maxQueue  int
+	retries   int
+	backoff   time.Duration
+
+	mu     sync.Mutex
+	queue  [][]byte
+	closed bool
+
+	sending sync.Mutex
+	kick    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+}
+
+func (e *otlpExporter) enqueue(record []byte) error {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return ErrShutdown
+	}
+
+	// queue is bounded if collector is not available, the newest record is dropped
+	if len(e.queue) >= e.maxQueue {
+		e.mu.Unlock()
+		return fmt.Errorf("otlp queue is full, record is dropped")
+	}
+
+	e.queue = append(e.queue, record)
+	full := len(e.queue) >= e.batchSize
+	e.mu.Unlock()
+
+	if full {
+		select {
+		case e.kick <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+func (e *otlpExporter) run(interval time.Duration) {
+	defer close(e.stopped)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.done:
+			return
+		case <-ticker.C:
+		case <-e.kick:
+		}
+
+		// background export has no caller to report the error
+		_ = e.export(context.Background())
+	}
+}
+
+// export sends all queued records in batches, failed batch is dropped
+// after retries are exhausted.
+func (e *otlpExporter) export(ctx context.Context) error {
+	e.sending.Lock()
+	defer e.sending.Unlock()
+
+	var errs []error
+	for {
+		e.mu.Lock()
+		n := len(e.queue)
+		if n > e.batchSize {
+			n = e.batchSize
+		}
+		batch := e.queue[:n:n]
+		e.queue = e.queue[n:]
+		e.mu.Unlock()
+
+		if len(batch) == 0 {
+			return errors.Join(errs...)
+		}
+
+		if err := e.send(ctx, batch); err != nil {
+			errs = append(errs, err)
+			if ctx.Err() != nil {
+				return errors.Join(errs...)
+			}
+		}
+	}
+}
+
+func (e *otlpExporter) send(ctx context.Context, batch [][]byte) error {
+	size := len(e.prefix) + len(otlpRequestSuffix) + len(batch)
+	for _, record := range batch {
+		size += len(record)
+	}
+
+	body := make([]byte, 0, size)
+	body = append(body, e.prefix...)
+	for i, record := range batch {
+		if i > 0 {
+			body = append(body, ',')
+		}
+		body = append(body, record...)
+	}
+	body = append(body, otlpRequestSuffix...)
+
+	for attempt := 0; ; attempt++ {
+		retry, err := e.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+
+		if !retry || attempt >= e.retries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(e.backoff << attempt):
+		}
+	}
+}
+
+// post sends request to collector, it returns true if the failure is transient
+func (e *otlpExporter) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for key, val := range e.headers {
+		req.Header.Set(key, val)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests,
+		resp.StatusCode == http.StatusBadGateway,
+		resp.StatusCode == http.StatusServiceUnavailable,
+		resp.StatusCode == http.StatusGatewayTimeout:
+		return true, fmt.Errorf("otlp export failed: %s", resp.Status)
+	default:
+		return false, fmt.Errorf("otlp export failed: %s", resp.Status)
+	}
+}
+
+func (e *otlpExporter) shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	e.closed = true
+	e.mu.Unlock()
+
+	e.once.Do(func() { close(e.done) })
+
+	select {
+	case <-e.stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return e.export(ctx)
+}
@@ -0,0 +1,216 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collector stand-in, it records export requests
+type otlpCollector struct {
+	sync.Mutex
+	status   []int
+	requests []map[string]any
+}
+
+func (c *otlpCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.Lock()
+	defer c.Unlock()
+
+	if len(c.status) > 0 {
+		status := c.status[0]
+		c.status = c.status[1:]
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+	}
+
+	var req map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	c.requests = append(c.requests, req)
+}
+
+func (c *otlpCollector) records() []map[string]any {
+	c.Lock()
+	defer c.Unlock()
+
+	seq := []map[string]any{}
+	for _, req := range c.requests {
+		rl := req["resourceLogs"].([]any)[0].(map[string]any)
+		sl := rl["scopeLogs"].([]any)[0].(map[string]any)
+		for _, r := range sl["logRecords"].([]any) {
+			seq = append(seq, r.(map[string]any))
+		}
+	}
+	return seq
+}
+
+func otlpAttrs(record map[string]any) map[string]any {
+	attrs := map[string]any{}
+	for _, x := range record["attributes"].([]any) {
+		kv := x.(map[string]any)
+		for _, v := range kv["value"].(map[string]any) {
+			attrs[kv["key"].(string)] = v
+		}
+	}
+	return attrs
+}
+
+func TestOTLPSeverity(t *testing.T) {
+	for level, expected := range map[slog.Level]int{
+		EMERGENCY: 24,
+		CRITICAL:  21,
+		ERROR:     17,
+		WARN:      13,
+		NOTICE:    10,
+		INFO:      9,
+		DEBUG:     5,
+	} {
+		if v := otlpSeverity(level); v != expected {
+			t.Errorf("unexpected severity %d of %s", v, level)
+		}
+	}
+}
+
+func TestOTLPHandler(t *testing.T) {
+	t.Run("Export", func(t *testing.T) {
+		c := &otlpCollector{}
+		ts := httptest.NewServer(c)
+		defer ts.Close()
+
+		h := NewOTLPHandler(ts.URL, WithOTLPResource(slog.String("service.version", "1.0.0")))
+		defer h.Shutdown(context.Background())
+
+		ctx := ContextWithTrace(context.Background(), Trace{
+			TraceID: "5b8efff798038103d269b633813fc60c",
+			SpanID:  "eee19b7ec3c1b174",
+			Sampled: true,
+		})
+		slog.New(h).With("a", 1).WithGroup("g").Log(ctx, NOTICE, "test", "b", "x")
+
+		if err := h.Flush(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		c.Lock()
+		resource := c.requests[0]["resourceLogs"].([]any)[0].(map[string]any)["resource"]
+		c.Unlock()
+		if attrs := otlpAttrs(resource.(map[string]any)); attrs["service.version"] != "1.0.0" || attrs["service.name"] == nil {
+			t.Errorf("unexpected resource %v", resource)
+		}
+
+		records := c.records()
+		if len(records) != 1 {
+			t.Fatalf("unexpected records %v", records)
+		}
+
+		r := records[0]
+		if r["severityNumber"] != 10.0 || r["severityText"] != "NOTICE" ||
+			r["body"].(map[string]any)["stringValue"] != "test" ||
+			r["traceId"] != "5b8efff798038103d269b633813fc60c" ||
+			r["spanId"] != "eee19b7ec3c1b174" ||
+			r["flags"] != 1.0 {
+			t.Errorf("unexpected record %v", r)
+		}
+
+		attrs := otlpAttrs(r)
+		if attrs["a"] != "1" || attrs["g.b"] != "x" ||
+			!strings.HasSuffix(attrs["code.filepath"].(string), "otlp_test.go") {
+			t.Errorf("unexpected attributes %v", attrs)
+		}
+	})
+
+	t.Run("Batch", func(t *testing.T) {
+		c := &otlpCollector{}
+		ts := httptest.NewServer(c)
+		defer ts.Close()
+
+		h := NewOTLPHandler(ts.URL, WithOTLPBatch(2, time.Hour))
+		defer h.Shutdown(context.Background())
+
+		log := slog.New(h)
+		log.Info("a")
+		log.Info("b")
+
+		for i := 0; i < 100 && len(c.records()) != 2; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		if records := c.records(); len(records) != 2 {
+			t.Errorf("unexpected records %v", records)
+		}
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		c := &otlpCollector{status: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}}
+		ts := httptest.NewServer(c)
+		defer ts.Close()
+
+		h := NewOTLPHandler(ts.URL, WithOTLPRetry(2, time.Millisecond))
+		defer h.Shutdown(context.Background())
+
+		slog.New(h).Info("test")
+		if err := h.Flush(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		if records := c.records(); len(records) != 1 {
+			t.Errorf("unexpected records %v", records)
+		}
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		c := &otlpCollector{status: []int{http.StatusBadRequest}}
+		ts := httptest.NewServer(c)
+		defer ts.Close()
+
+		h := NewOTLPHandler(ts.URL, WithOTLPRetry(2, time.Millisecond))
+		defer h.Shutdown(context.Background())
+
+		slog.New(h).Info("test")
+		if err := h.Flush(context.Background()); err == nil {
+			t.Errorf("error is expected")
+		}
+	})
+
+	t.Run("Shutdown", func(t *testing.T) {
+		c := &otlpCollector{}
+		ts := httptest.NewServer(c)
+		defer ts.Close()
+
+		h := NewOTLPHandler(ts.URL)
+		slog.New(h).Info("test")
+
+		if err := h.Shutdown(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		if records := c.records(); len(records) != 1 {
+			t.Errorf("unexpected records %v", records)
+		}
+
+		r := slog.NewRecord(time.Now(), INFO, "test", 0)
+		if err := h.Handle(context.Background(), r); !errors.Is(err, ErrShutdown) {
+			t.Errorf("unexpected error %v", err)
+		}
+	})
+}
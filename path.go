@@ -0,0 +1,119 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"path"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// modPath normalises the path to source file into the import path of the
+// package, it is used by module rules and source shortening. It understands
+//
+// * GOPATH layout: /home/you/go/src/github.com/fogfish/logger/logger.go
+//
+// * Go module cache: /home/you/go/pkg/mod/github.com/fogfish/logger@v1.0.0/logger.go
+//
+// * Vendored packages: /home/you/app/vendor/github.com/fogfish/logger/logger.go
+//
+// * Binaries built with -trimpath: github.com/fogfish/logger@v1.0.0/logger.go
+//
+// * Main module checked out anywhere, the import path is derived from
+// the function name or the main module path (see debug.ReadBuildInfo).
+func modPath(file, function string) string {
+	if at := strings.LastIndex(file, "/pkg/mod/"); at != -1 {
+		return unescapeModPath(stripModVersion(file[at+len("/pkg/mod/"):]))
+	}
+
+	if at := strings.LastIndex(file, "/vendor/"); at != -1 {
+		return file[at+len("/vendor/"):]
+	}
+
+	if at := strings.Index(file, "go/src/"); at != -1 {
+		return file[at+len("go/src/"):]
+	}
+
+	if !isAbsPath(file) {
+		return stripModVersion(file)
+	}
+
+	// absolute path to the file of main module
+	if pkg := pkgOf(function); pkg != "" && pkg != "main" {
+		return pkg + "/" + path.Base(file)
+	}
+
+	if mod := mainModPath(); mod != "" {
+		return mod + "/" + path.Base(file)
+	}
+
+	return file
+}
+
+// the path is absolute either at unix or windows system, runtime uses
+// forward slashes at both.
+func isAbsPath(file string) bool {
+	return strings.HasPrefix(file, "/") ||
+		(len(file) > 2 && file[1] == ':' && file[2] == '/')
+}
+
+// strips @version suffix from the module segment of the path
+func stripModVersion(file string) string {
+	at := strings.IndexByte(file, '@')
+	if at == -1 {
+		return file
+	}
+
+	end := strings.IndexByte(file[at:], '/')
+	if end == -1 {
+		return file[:at]
+	}
+
+	return file[:at] + file[at+end:]
+}
+
+// module cache escapes upper case letters as !{lower case} (e.g. !burnt!sushi)
+func unescapeModPath(file string) string {
+	if strings.IndexByte(file, '!') == -1 {
+		return file
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(file))
+	for i := 0; i < len(file); i++ {
+		c := file[i]
+		if c == '!' && i+1 < len(file) && 'a' <= file[i+1] && file[i+1] <= 'z' {
+			i++
+			c = file[i] - 'a' + 'A'
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// import path of the package the function belongs to
+// (e.g. github.com/fogfish/logger.(*T).Func)
+func pkgOf(function string) string {
+	slash := strings.LastIndexByte(function, '/')
+	dot := strings.IndexByte(function[slash+1:], '.')
+	if dot == -1 {
+		return ""
+	}
+
+	return function[:slash+1+dot]
+}
+
+var mainModPath = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	return info.Main.Path
+})
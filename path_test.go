@@ -0,0 +1,65 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import "testing"
+
+func TestModPath(t *testing.T) {
+	for _, tc := range []struct{ file, function, expected string }{
+		{
+			file:     "/home/you/go/src/github.com/fogfish/logger/logger.go",
+			function: "github.com/fogfish/logger.New",
+			expected: "github.com/fogfish/logger/logger.go",
+		},
+		{
+			file:     "/root/go/pkg/mod/github.com/aws/aws-sdk-go-v2@v1.30.0/aws/config.go",
+			function: "github.com/aws/aws-sdk-go-v2/aws.NewConfig",
+			expected: "github.com/aws/aws-sdk-go-v2/aws/config.go",
+		},
+		{
+			file:     "/root/go/pkg/mod/github.com/!burnt!sushi/toml@v1.3.2/decode.go",
+			function: "github.com/BurntSushi/toml.Decode",
+			expected: "github.com/BurntSushi/toml/decode.go",
+		},
+		{
+			file:     "/home/you/app/vendor/github.com/fogfish/logger/logger.go",
+			function: "github.com/fogfish/logger.New",
+			expected: "github.com/fogfish/logger/logger.go",
+		},
+		{
+			file:     "github.com/fogfish/logger/v3@v3.2.0/logger.go",
+			function: "github.com/fogfish/logger/v3.New",
+			expected: "github.com/fogfish/logger/v3/logger.go",
+		},
+		{
+			file:     "github.com/you/app/internal/app.go",
+			function: "github.com/you/app/internal.Run",
+			expected: "github.com/you/app/internal/app.go",
+		},
+		{
+			file:     "/home/you/workspace/app/internal/app.go",
+			function: "github.com/you/app/internal.(*T).Run",
+			expected: "github.com/you/app/internal/app.go",
+		},
+		{
+			file:     "C:/Users/you/app/internal/app.go",
+			function: "github.com/you/app/internal.Run",
+			expected: "github.com/you/app/internal/app.go",
+		},
+		{
+			file:     "/home/you/workspace/app/main.go",
+			function: "main.main",
+			expected: mainModPath() + "/main.go",
+		},
+	} {
+		if v := modPath(tc.file, tc.function); v != tc.expected {
+			t.Errorf("unexpected path %s for %s, expected %s", v, tc.file, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// recorderHandler is the flight recorder, it performs module-based logging
+// (see modTrieHandler) but records discarded by rules are kept in the ring
+// buffer. The buffer is replayed before ERROR and above, replayed records
+// have top-level attribute replayed=true. Replayed records are not sampled,
+// they are handled by the root handler, the one below sampling.
+type recorderHandler struct {
+	slog.Handler
+	root     slog.Handler
+	goas     []groupOrAttrs
+	rules    *atomic.Pointer[modRules]
+	recorder *recorder
+	replayer *recorderReplayer
+}
+
+// recorderReplayer is the root handler derived with groups and attributes
+// of the recorder handler, it is built once by the first replay.
+type recorderReplayer struct {
+	once    sync.Once
+	h       slog.Handler
+	grouped bool
+}
+
+func newRecorderHandler(h, root slog.Handler, rules *atomic.Pointer[modRules], size int, key func(context.Context) string) slog.Handler {
+	return &recorderHandler{
+		Handler:  h,
+		root:     root,
+		rules:    rules,
+		replayer: &recorderReplayer{},
+		recorder: &recorder{
+			size:  size,
+			key:   key,
+			rings: map[string]*recorderRing{},
+		},
+	}
+}
+
+// Enabled accepts DEBUG and above, records discarded by rules are recorded
+func (h *recorderHandler) Enabled(_ context.Context, level slog.Level) bool {
+	rules := h.rules.Load()
+	return level >= DEBUG || level >= rules.min || level >= rules.fallback.Level()
+}
+
+func (h *recorderHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return h.withGroupOrAttrs(groupOrAttrs{attrs: attrs}, h.Handler.WithAttrs(attrs))
+}
+
+func (h *recorderHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.withGroupOrAttrs(groupOrAttrs{group: name}, h.Handler.WithGroup(name))
+}
+
+func (h *recorderHandler) withGroupOrAttrs(goa groupOrAttrs, handler slog.Handler) *recorderHandler {
+	h2 := *h
+	h2.Handler = handler
+	h2.goas = appendGroupOrAttrs(h.goas, goa)
+	h2.replayer = &recorderReplayer{}
+	return &h2
+}
+
+func (h *recorderHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < h.rules.Load().levelOf(r.PC) {
+		h.recorder.push(ctx, recorderEntry{ctx: ctx, r: r.Clone(), h: h})
+		return nil
+	}
+
+	if r.Level < ERROR {
+		return h.Handler.Handle(ctx, r)
+	}
+
+	var errs []error
+	for _, e := range h.recorder.pop(ctx) {
+		if err := e.h.replay(e.ctx, e.r); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := h.Handler.Handle(ctx, r); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// replay handles the record with top-level attribute replayed=true
+func (h *recorderHandler) replay(ctx context.Context, r slog.Record) error {
+	replayed := slog.Bool("replayed", true)
+	replayer := h.replayer
+
+	replayer.once.Do(func() {
+		for _, goa := range h.goas {
+			replayer.grouped = replayer.grouped || goa.group != ""
+		}
+
+		if replayer.grouped {
+			replayer.h = applyGroupOrAttrs(h.root.WithAttrs([]slog.Attr{replayed}), h.goas)
+		} else {
+			replayer.h = applyGroupOrAttrs(h.root, h.goas)
+		}
+	})
+
+	if !replayer.grouped {
+		r.AddAttrs(replayed)
+	}
+
+	return replayer.h.Handle(ctx, r)
+}
+
+//------------------------------------------------------------------------------
+
+type recorderEntry struct {
+	ctx context.Context
+	r   slog.Record
+	h   *recorderHandler
+}
+
+// recorderRing is bounded ring of recent records, the oldest record is
+// overwritten if the ring is full.
+type recorderRing struct {
+	ring []recorderEntry
+	head int
+	size int
+	seq  uint64      // the sequence number of the last push, used by eviction
+	stop func() bool // stops release of the ring at the end of context
+}
+
+func (q *recorderRing) push(e recorderEntry) {
+	if q.size < len(q.ring) {
+		q.ring[(q.head+q.size)%len(q.ring)] = e
+		q.size++
+		return
+	}
+
+	q.ring[q.head] = e
+	q.head = (q.head + 1) % len(q.ring)
+}
+
+// The number of context keys tracked by recorder, the least recently used
+// key is evicted if exceeded
+const recorderMaxKeys = 1024
+
+type recorder struct {
+	sync.Mutex
+	size  int
+	key   func(context.Context) string
+	rings map[string]*recorderRing
+	seq   uint64
+}
+
+func (rec *recorder) keyOf(ctx context.Context) string {
+	if rec.key == nil {
+		return ""
+	}
+	return rec.key(ctx)
+}
+
+func (rec *recorder) push(ctx context.Context, e recorderEntry) {
+	key := rec.keyOf(ctx)
+
+	rec.Lock()
+	defer rec.Unlock()
+
+	ring, has := rec.rings[key]
+	if !has {
+		if key != "" && len(rec.rings) >= recorderMaxKeys {
+			rec.evict()
+		}
+
+		ring = &recorderRing{ring: make([]recorderEntry, rec.size)}
+		if key != "" {
+			// the ring of the key is released at the end of request
+			ring.stop = context.AfterFunc(ctx, func() { rec.release(key, ring) })
+		}
+		rec.rings[key] = ring
+	}
+
+	rec.seq++
+	ring.seq = rec.seq
+	ring.push(e)
+}
+
+// evict removes the least recently used ring, the global ring is never evicted
+func (rec *recorder) evict() {
+	lru := ""
+	for key, ring := range rec.rings {
+		if key != "" && (lru == "" || ring.seq < rec.rings[lru].seq) {
+			lru = key
+		}
+	}
+
+	if lru != "" {
+		rec.remove(lru)
+	}
+}
+
+func (rec *recorder) release(key string, ring *recorderRing) {
+	rec.Lock()
+	defer rec.Unlock()
+
+	if rec.rings[key] == ring {
+		delete(rec.rings, key)
+	}
+}
+
+func (rec *recorder) remove(key string) *recorderRing {
+	ring := rec.rings[key]
+	delete(rec.rings, key)
+	if ring.stop != nil {
+		ring.stop()
+	}
+	return ring
+}
+
+// pop removes recorded records, the oldest record is first
+func (rec *recorder) pop(ctx context.Context) []recorderEntry {
+	key := rec.keyOf(ctx)
+
+	rec.Lock()
+	defer rec.Unlock()
+
+	if _, has := rec.rings[key]; !has {
+		return nil
+	}
+	ring := rec.remove(key)
+
+	seq := make([]recorderEntry, ring.size)
+	for i := 0; i < ring.size; i++ {
+		seq[i] = ring.ring[(ring.head+i)%len(ring.ring)]
+	}
+
+	return seq
+}
@@ -0,0 +1,229 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type recorderKey string
+
+func TestFlightRecorder(t *testing.T) {
+	t.Run("Replay", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		log := slog.New(NewJSONHandler(WithWriter(b), WithLogLevel(INFO), WithFlightRecorder(3)))
+
+		for i := 0; i < 5; i++ {
+			log.Debug("debug", "i", i)
+		}
+		log.Info("info")
+
+		lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+		if len(lines) != 1 || !strings.Contains(lines[0], `"msg":"info"`) {
+			t.Fatalf("unexpected log lines %s", b.String())
+		}
+
+		log.Error("error")
+
+		lines = strings.Split(strings.TrimSpace(b.String()), "\n")
+		if len(lines) != 5 ||
+			!strings.Contains(lines[1], `"level":"DEBUG"`) ||
+			!strings.Contains(lines[1], `"msg":"debug","i":2,"replayed":true`) ||
+			!strings.Contains(lines[3], `"msg":"debug","i":4,"replayed":true`) ||
+			!strings.Contains(lines[4], `"msg":"error"`) ||
+			strings.Contains(lines[4], `replayed`) {
+			t.Errorf("unexpected log lines %s", b.String())
+		}
+
+		// the buffer is empty after replay
+		b.Reset()
+		log.Error("error")
+		if lines := strings.Split(strings.TrimSpace(b.String()), "\n"); len(lines) != 1 {
+			t.Errorf("unexpected log lines %s", b.String())
+		}
+	})
+
+	t.Run("Group", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		log := slog.New(NewJSONHandler(WithWriter(b), WithFlightRecorder(3))).With("a", 1).WithGroup("g")
+
+		log.Debug("debug", "i", 1)
+		log.Error("error", "i", 2)
+
+		lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+		if len(lines) != 2 ||
+			!strings.Contains(lines[0], `"replayed":true,"a":1,"g":{"i":1}`) ||
+			!strings.Contains(lines[1], `"a":1,"g":{"i":2}`) {
+			t.Errorf("unexpected log lines %s", b.String())
+		}
+	})
+
+	t.Run("Sampling", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		log := slog.New(NewJSONHandler(WithWriter(b), WithLogLevel(INFO), WithFlightRecorder(3),
+			WithSampling(1, 0, time.Hour)))
+
+		for i := 0; i < 3; i++ {
+			log.Debug("debug", "i", i)
+		}
+		log.Error("error")
+
+		lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+		if len(lines) != 4 ||
+			!strings.Contains(lines[2], `"msg":"debug","i":2,"replayed":true`) ||
+			strings.Contains(b.String(), `sampling_dropped`) {
+			t.Errorf("replayed records are sampled %s", b.String())
+		}
+	})
+
+	t.Run("Replayer", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		h := NewJSONHandler(WithWriter(b), WithFlightRecorder(3)).WithAttrs([]slog.Attr{slog.Int("a", 1)}).WithGroup("g")
+		log := slog.New(h)
+
+		log.Debug("debug", "i", 1)
+		log.Error("error", "i", 2)
+
+		replayer := h.(*recorderHandler).replayer.h
+		log.Debug("debug", "i", 3)
+		log.Error("error", "i", 4)
+
+		lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+		if len(lines) != 4 || !strings.Contains(lines[2], `"replayed":true,"a":1,"g":{"i":3}`) {
+			t.Errorf("unexpected log lines %s", b.String())
+		}
+
+		if replayer == nil || replayer != h.(*recorderHandler).replayer.h {
+			t.Errorf("replay handler is not reused")
+		}
+	})
+
+	t.Run("Mods", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		log := slog.New(NewJSONHandler(WithWriter(b), WithFlightRecorder(3),
+			WithLogLevelForMod(map[string]slog.Level{
+				"github.com/fogfish/logger": DEBUG,
+				"*":                         ERROR,
+			}),
+		))
+
+		log.Debug("debug")
+		log.Error("error")
+
+		lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+		if len(lines) != 2 || strings.Contains(b.String(), `replayed`) {
+			t.Errorf("unexpected log lines %s", b.String())
+		}
+	})
+
+	t.Run("Key", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		log := slog.New(NewJSONHandler(WithWriter(b), WithFlightRecorder(3),
+			WithFlightRecorderKey(func(ctx context.Context) string {
+				id, _ := ctx.Value(recorderKey("id")).(string)
+				return id
+			}),
+		))
+
+		a := context.WithValue(context.Background(), recorderKey("id"), "a")
+		z := context.WithValue(context.Background(), recorderKey("id"), "z")
+
+		log.DebugContext(a, "debug", "id", "a")
+		log.DebugContext(z, "debug", "id", "z")
+		log.ErrorContext(a, "error", "id", "a")
+
+		lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+		if len(lines) != 2 ||
+			!strings.Contains(lines[0], `"msg":"debug","id":"a","replayed":true`) ||
+			!strings.Contains(lines[1], `"msg":"error","id":"a"`) {
+			t.Errorf("unexpected log lines %s", b.String())
+		}
+	})
+
+	t.Run("KeyRelease", func(t *testing.T) {
+		h := NewJSONHandler(WithWriter(&bytes.Buffer{}), WithFlightRecorder(3),
+			WithFlightRecorderKey(func(ctx context.Context) string {
+				id, _ := ctx.Value(recorderKey("id")).(string)
+				return id
+			}),
+		)
+		rec := h.(*recorderHandler).recorder
+		log := slog.New(h)
+
+		log.Debug("debug")
+		ctx, cancel := context.WithCancel(context.WithValue(context.Background(), recorderKey("id"), "a"))
+		log.DebugContext(ctx, "debug")
+		cancel()
+
+		for i := 0; i < 100; i++ {
+			rec.Lock()
+			_, has := rec.rings["a"]
+			rec.Unlock()
+			if !has {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		rec.Lock()
+		defer rec.Unlock()
+		if _, has := rec.rings["a"]; has {
+			t.Errorf("ring of canceled context is not released")
+		}
+		if _, has := rec.rings[""]; !has {
+			t.Errorf("global ring is released")
+		}
+	})
+
+	t.Run("KeyEvict", func(t *testing.T) {
+		h := NewJSONHandler(WithWriter(&bytes.Buffer{}), WithFlightRecorder(3),
+			WithFlightRecorderKey(func(ctx context.Context) string {
+				id, _ := ctx.Value(recorderKey("id")).(string)
+				return id
+			}),
+		)
+		rec := h.(*recorderHandler).recorder
+		log := slog.New(h)
+
+		log.Debug("debug")
+		for i := 0; i <= recorderMaxKeys; i++ {
+			ctx := context.WithValue(context.Background(), recorderKey("id"), strconv.Itoa(i))
+			log.DebugContext(ctx, "debug")
+		}
+
+		rec.Lock()
+		defer rec.Unlock()
+		if _, has := rec.rings[""]; !has || len(rec.rings) != recorderMaxKeys {
+			t.Errorf("global ring is evicted")
+		}
+		if _, has := rec.rings["0"]; has {
+			t.Errorf("least recently used ring is not evicted")
+		}
+	})
+
+	t.Run("Control", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		ctl := &Control{}
+		log := slog.New(NewJSONHandler(WithWriter(b), WithFlightRecorder(3), WithControl(ctl)))
+
+		log.Debug("debug")
+		ctl.SetLevel(DEBUG)
+		log.Debug("debug")
+
+		if lines := strings.Split(strings.TrimSpace(b.String()), "\n"); len(lines) != 1 {
+			t.Errorf("unexpected log lines %s", b.String())
+		}
+	})
+}
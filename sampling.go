@@ -0,0 +1,203 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"container/list"
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sampling configuration: first records per window are logged, then every
+// Nth record is logged.
+type sampling struct {
+	first  int
+	every  int
+	window time.Duration
+}
+
+// parseSampling parses the sampling configuration
+//
+//	first:100,every:50,window:1s
+func parseSampling(spec string) (*sampling, bool) {
+	s := &sampling{first: 100, every: 100, window: time.Second}
+
+	for _, kv := range strings.Split(spec, ",") {
+		key, val, has := strings.Cut(strings.TrimSpace(kv), ":")
+		if !has {
+			return nil, false
+		}
+
+		var err error
+		switch key {
+		case "first":
+			s.first, err = strconv.Atoi(val)
+		case "every":
+			s.every, err = strconv.Atoi(val)
+		case "window":
+			s.window, err = time.ParseDuration(val)
+		default:
+			return nil, false
+		}
+
+		if err != nil || s.first < 0 || s.every < 0 || s.window <= 0 {
+			return nil, false
+		}
+	}
+
+	return s, true
+}
+
+//------------------------------------------------------------------------------
+
+// samplingHandler samples records below WARN level, records are sampled per
+// call-site and message. The number of dropped records is reported by
+// the next record of the call-site.
+type samplingHandler struct {
+	slog.Handler
+	sampler *sampler
+}
+
+func newSamplingHandler(h slog.Handler, config *sampling) slog.Handler {
+	return &samplingHandler{
+		Handler: h,
+		sampler: &sampler{sampling: *config, keys: map[samplingKey]*samplingState{}},
+	}
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithAttrs(attrs), sampler: h.sampler}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithGroup(name), sampler: h.sampler}
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= WARN {
+		return h.Handler.Handle(ctx, r)
+	}
+
+	pass, dropped := h.sampler.sample(r.PC, r.Message, r.Time)
+	if !pass {
+		return nil
+	}
+
+	if dropped != 0 {
+		// the attribute belongs to the record, it is nested into the group
+		// if the group is defined
+		r = r.Clone()
+		r.AddAttrs(slog.Int("sampling_dropped", dropped))
+	}
+
+	return h.Handler.Handle(ctx, r)
+}
+
+//------------------------------------------------------------------------------
+
+type samplingKey struct {
+	pc  uintptr
+	msg string
+}
+
+type samplingState struct {
+	key     samplingKey
+	start   time.Time
+	n       int
+	dropped int
+	elem    *list.Element // the position at LRU list
+	lru     *list.List
+}
+
+// The number of call-sites tracked by sampler, the least recently seen
+// call-site is evicted if exceeded
+const samplingMaxKeys = 4096
+
+type sampler struct {
+	sync.Mutex
+	sampling
+	keys map[samplingKey]*samplingState
+
+	// call-sites ordered by recent use, call-sites having unreported dropped
+	// records are kept apart, they are evicted only if there is no other choice.
+	clean   list.List
+	pending list.List
+}
+
+// sample returns true if the record is logged and number of records dropped
+// since the last logged one.
+func (s *sampler) sample(pc uintptr, msg string, t time.Time) (bool, int) {
+	if t.IsZero() {
+		t = time.Now()
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	key := samplingKey{pc: pc, msg: msg}
+	state, has := s.keys[key]
+	if !has {
+		if len(s.keys) >= samplingMaxKeys {
+			s.evict()
+		}
+		state = &samplingState{key: key, start: t}
+		s.keys[key] = state
+	}
+	defer s.touch(state)
+
+	if t.Sub(state.start) >= s.window {
+		state.start, state.n = t, 0
+	}
+
+	state.n++
+	if state.n <= s.first || (s.every > 0 && (state.n-s.first)%s.every == 0) {
+		dropped := state.dropped
+		state.dropped = 0
+		return true, dropped
+	}
+
+	state.dropped++
+	return false, 0
+}
+
+// touch moves the call-site to the front of its LRU list
+func (s *sampler) touch(state *samplingState) {
+	lru := &s.clean
+	if state.dropped != 0 {
+		lru = &s.pending
+	}
+
+	if state.lru == lru {
+		lru.MoveToFront(state.elem)
+		return
+	}
+
+	if state.lru != nil {
+		state.lru.Remove(state.elem)
+	}
+	state.elem, state.lru = lru.PushFront(state), lru
+}
+
+// evict removes the least recently seen call-site
+func (s *sampler) evict() {
+	e := s.clean.Back()
+	if e == nil {
+		e = s.pending.Back()
+	}
+
+	if e != nil {
+		state := e.Value.(*samplingState)
+		state.lru.Remove(e)
+		delete(s.keys, state.key)
+	}
+}
@@ -0,0 +1,137 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSampling(t *testing.T) {
+	for spec, expected := range map[string]*sampling{
+		"first:10,every:5,window:2s": {first: 10, every: 5, window: 2 * time.Second},
+		"first:10":                   {first: 10, every: 100, window: time.Second},
+		"every:0, window:100ms":      {first: 100, every: 0, window: 100 * time.Millisecond},
+		"first:-1":                   nil,
+		"window:0s":                  nil,
+		"first":                      nil,
+		"last:10":                    nil,
+	} {
+		s, ok := parseSampling(spec)
+		if ok != (expected != nil) || (ok && *s != *expected) {
+			t.Errorf("unexpected sampling %v of %s", s, spec)
+		}
+	}
+}
+
+func TestSampler(t *testing.T) {
+	s := &sampler{
+		sampling: sampling{first: 2, every: 3, window: time.Second},
+		keys:     map[samplingKey]*samplingState{},
+	}
+
+	t0 := time.Now()
+	seq := []string{}
+	for i := 0; i < 10; i++ {
+		pass, dropped := s.sample(1, "test", t0)
+		if pass {
+			seq = append(seq, strings.Repeat("+", dropped+1))
+		}
+	}
+
+	if v := strings.Join(seq, ","); v != "+,+,+++,+++" {
+		t.Errorf("unexpected sampling %s", v)
+	}
+
+	// other call-site is sampled independently
+	if pass, _ := s.sample(2, "test", t0); !pass {
+		t.Errorf("call-site is not sampled independently")
+	}
+
+	// window is over
+	if pass, dropped := s.sample(1, "test", t0.Add(time.Second)); !pass || dropped != 2 {
+		t.Errorf("unexpected sampling after window %v %d", pass, dropped)
+	}
+}
+
+func TestSamplerEvict(t *testing.T) {
+	s := &sampler{
+		sampling: sampling{first: 1, every: 0, window: time.Hour},
+		keys:     map[samplingKey]*samplingState{},
+	}
+
+	t0 := time.Now()
+
+	// call-site 0 has dropped records, it is the least recently seen
+	s.sample(0, "test", t0)
+	s.sample(0, "test", t0)
+	for pc := uintptr(1); pc < samplingMaxKeys; pc++ {
+		s.sample(pc, "test", t0.Add(time.Duration(pc)))
+	}
+
+	s.sample(samplingMaxKeys, "test", t0.Add(time.Minute))
+
+	if _, has := s.keys[samplingKey{pc: 0, msg: "test"}]; !has {
+		t.Errorf("call-site with dropped records is evicted")
+	}
+
+	if _, has := s.keys[samplingKey{pc: 1, msg: "test"}]; has || len(s.keys) != samplingMaxKeys {
+		t.Errorf("least recently seen call-site is not evicted")
+	}
+}
+
+func TestSamplingHandler(t *testing.T) {
+	b := &bytes.Buffer{}
+
+	t.Run("Sampling", func(t *testing.T) {
+		defer b.Reset()
+
+		log := slog.New(NewJSONHandler(WithWriter(b), WithSampling(2, 3, time.Hour)))
+		for i := 0; i < 10; i++ {
+			log.Info("test", "i", i)
+		}
+
+		lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+		if len(lines) != 4 ||
+			!strings.Contains(lines[2], `"i":4,"sampling_dropped":2`) ||
+			!strings.Contains(lines[3], `"i":7,"sampling_dropped":2`) {
+			t.Errorf("unexpected log lines %s", b.String())
+		}
+	})
+
+	t.Run("Warn", func(t *testing.T) {
+		defer b.Reset()
+
+		log := slog.New(NewJSONHandler(WithWriter(b), WithSampling(1, 0, time.Hour)))
+		for i := 0; i < 10; i++ {
+			log.Warn("test")
+		}
+
+		if n := strings.Count(b.String(), "\n"); n != 10 {
+			t.Errorf("WARN is sampled %s", b.String())
+		}
+	})
+
+	t.Run("FromEnv", func(t *testing.T) {
+		defer b.Reset()
+		t.Setenv("CONFIG_LOG_SAMPLE", "first:1,every:0")
+
+		log := slog.New(NewJSONHandler(WithWriter(b)))
+		for i := 0; i < 10; i++ {
+			log.Info("test")
+		}
+
+		if n := strings.Count(b.String(), "\n"); n != 1 {
+			t.Errorf("unexpected log lines %s", b.String())
+		}
+	})
+}
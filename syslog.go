@@ -0,0 +1,329 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SyslogHandler emits records as RFC 5424 frames over UDP, TCP or unix
+// socket. The connection is established lazily and re-established if
+// the write fails. Writes fail fast if syslog is not reachable, the connection
+// is re-attempted every 5 seconds.
+type SyslogHandler struct {
+	slog.Handler
+	conn *syslogConn
+}
+
+// The structured data element of slog attributes, the private enterprise
+// number 32473 is reserved for documentation (RFC 5612).
+const syslogSDID = "slog@32473"
+
+// Syslog logger handler, network is either "udp", "tcp", "unix" or
+// "unixgram". Stream oriented transports ("tcp", "unix") uses octet-counting
+// framing (RFC 6587).
+//
+//	h := logger.NewSyslogHandler("udp", "localhost:514")
+//	defer h.Close()
+//
+// The 7 log levels are mapped one-to-one to syslog severities, attributes
+// are emitted as structured data, groups are flattened with dotted keys.
+func NewSyslogHandler(network, addr string, opts ...Option) *SyslogHandler {
+	config := defaultOpts(Syslog...)
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	if config.appName == "" {
+		config.appName = filepath.Base(os.Args[0])
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	conn := &syslogConn{network: network, addr: addr}
+	h := &syslogHandler{
+		conn:     conn,
+		level:    config.level,
+		source:   config.addSource,
+		attrs:    config.attributes,
+		facility: config.facility,
+		hostname: syslogHeader(hostname, 255),
+		appName:  syslogHeader(config.appName, 48),
+		procID:   strconv.Itoa(os.Getpid()),
+		msgID:    syslogHeader(config.msgID, 32),
+	}
+
+	return &SyslogHandler{
+		Handler: newHandlerChain(h, config),
+		conn:    conn,
+	}
+}
+
+// Close connection to syslog
+func (h *SyslogHandler) Close() error {
+	return h.conn.Close()
+}
+
+//------------------------------------------------------------------------------
+
+type syslogHandler struct {
+	conn     *syslogConn
+	level    slog.Leveler
+	source   bool
+	attrs    Attributes
+	goas     []groupOrAttrs
+	facility int
+	hostname string
+	appName  string
+	procID   string
+	msgID    string
+}
+
+func (h *syslogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *syslogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return h.withGroupOrAttrs(groupOrAttrs{attrs: attrs})
+}
+
+func (h *syslogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.withGroupOrAttrs(groupOrAttrs{group: name})
+}
+
+func (h *syslogHandler) withGroupOrAttrs(goa groupOrAttrs) *syslogHandler {
+	h2 := *h
+	h2.goas = appendGroupOrAttrs(h.goas, goa)
+	return &h2
+}
+
+func (h *syslogHandler) Handle(ctx context.Context, r slog.Record) error {
+	buf := newBuffer()
+	defer buf.free()
+
+	// HEADER
+	*buf = append(*buf, '<')
+	*buf = strconv.AppendInt(*buf, int64(h.facility*8+syslogSeverity(r.Level)), 10)
+	*buf = append(*buf, '>', '1', ' ')
+	if r.Time.IsZero() {
+		*buf = append(*buf, '-')
+	} else {
+		*buf = r.Time.AppendFormat(*buf, "2006-01-02T15:04:05.000000Z07:00")
+	}
+	*buf = append(*buf, ' ')
+	*buf = append(*buf, h.hostname...)
+	*buf = append(*buf, ' ')
+	*buf = append(*buf, h.appName...)
+	*buf = append(*buf, ' ')
+	*buf = append(*buf, h.procID...)
+	*buf = append(*buf, ' ')
+	*buf = append(*buf, h.msgID...)
+	*buf = append(*buf, ' ')
+
+	// STRUCTURED-DATA
+	at := len(*buf)
+	*buf = append(*buf, '[')
+	*buf = append(*buf, syslogSDID...)
+	sd := len(*buf)
+
+	walkFlatAttrs(h.attrs, h.goas, r, func(key string, v slog.Value) {
+		*buf = appendSyslogParam(*buf, key, v)
+	})
+
+	if h.source && r.PC != 0 {
+		a := h.attrs.handle(nil, slog.Any(slog.SourceKey, sourceOf(r.PC)))
+		if src, ok := a.Value.Any().(*slog.Source); ok && src != nil {
+			*buf = appendSyslogParam(*buf, slog.SourceKey, slog.StringValue(fmt.Sprintf("%s:%d", src.File, src.Line)))
+		}
+	}
+
+	if len(*buf) == sd {
+		*buf = append((*buf)[:at], '-')
+	} else {
+		*buf = append(*buf, ']')
+	}
+
+	// MSG
+	*buf = append(*buf, ' ')
+	*buf = append(*buf, r.Message...)
+
+	return h.conn.Write(*buf)
+}
+
+// syslog severity of the level
+func syslogSeverity(level slog.Level) int {
+	switch {
+	case level >= EMERGENCY:
+		return 0
+	case level >= CRITICAL:
+		return 2
+	case level >= ERROR:
+		return 3
+	case level >= WARN:
+		return 4
+	case level >= NOTICE:
+		return 5
+	case level >= INFO:
+		return 6
+	default:
+		return 7
+	}
+}
+
+// header fields are printable US-ASCII, "-" is nil value
+func syslogHeader(s string, size int) string {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s) && len(b) < size; i++ {
+		if s[i] > 32 && s[i] < 127 {
+			b = append(b, s[i])
+		}
+	}
+
+	if len(b) == 0 {
+		return "-"
+	}
+	return string(b)
+}
+
+// SD-PARAM = PARAM-NAME "=" %d34 PARAM-VALUE %d34
+func appendSyslogParam(buf []byte, key string, v slog.Value) []byte {
+	buf = append(buf, ' ')
+
+	n := 0
+	for i := 0; i < len(key) && n < 32; i++ {
+		c := key[i]
+		switch {
+		case c <= 32 || c >= 127 || c == '=' || c == ']' || c == '"':
+			buf = append(buf, '_')
+		default:
+			buf = append(buf, c)
+		}
+		n++
+	}
+	if n == 0 {
+		buf = append(buf, '_')
+	}
+
+	buf = append(buf, '=', '"')
+	at := len(buf)
+	buf = appendText(buf, v)
+
+	// escape '"', '\' and ']' in place
+	for i := at; i < len(buf); i++ {
+		switch buf[i] {
+		case '"', '\\', ']':
+			buf = append(buf, 0)
+			copy(buf[i+1:], buf[i:])
+			buf[i] = '\\'
+			i++
+		}
+	}
+
+	return append(buf, '"')
+}
+
+//------------------------------------------------------------------------------
+
+// The interval of reconnect attempts if syslog is not reachable, writes fail
+// fast within the interval.
+const syslogRedial = 5 * time.Second
+
+// syslogConn is connection to syslog, it is re-established on failures
+type syslogConn struct {
+	sync.Mutex
+	network  string
+	addr     string
+	conn     net.Conn
+	redialAt time.Time
+	dialErr  error
+}
+
+func (c *syslogConn) Write(msg []byte) error {
+	c.Lock()
+	defer c.Unlock()
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if c.conn == nil {
+			if err = c.dial(); err != nil {
+				return err
+			}
+		}
+
+		if err = c.write(msg); err == nil {
+			return nil
+		}
+
+		c.conn.Close()
+		c.conn = nil
+	}
+
+	return err
+}
+
+// dial connects to syslog, the error of failed attempt is returned until
+// the redial interval is over.
+func (c *syslogConn) dial() error {
+	if time.Now().Before(c.redialAt) {
+		return c.dialErr
+	}
+
+	conn, err := net.DialTimeout(c.network, c.addr, 5*time.Second)
+	if err != nil {
+		c.redialAt = time.Now().Add(syslogRedial)
+		c.dialErr = err
+		return err
+	}
+
+	c.conn = conn
+	return nil
+}
+
+func (c *syslogConn) write(msg []byte) error {
+	switch c.network {
+	case "tcp", "tcp4", "tcp6", "unix":
+		// octet-counting framing
+		frame := net.Buffers{strconv.AppendInt(nil, int64(len(msg)), 10), []byte{' '}, msg}
+		_, err := frame.WriteTo(c.conn)
+		return err
+	default:
+		_, err := c.conn.Write(msg)
+		return err
+	}
+}
+
+func (c *syslogConn) Close() error {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.conn == nil {
+		return nil
+	}
+
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
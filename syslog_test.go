@@ -0,0 +1,197 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogSeverity(t *testing.T) {
+	for level, expected := range map[slog.Level]int{
+		EMERGENCY: 0,
+		CRITICAL:  2,
+		ERROR:     3,
+		WARN:      4,
+		NOTICE:    5,
+		INFO:      6,
+		DEBUG:     7,
+	} {
+		if v := syslogSeverity(level); v != expected {
+			t.Errorf("unexpected severity %d of %s", v, level)
+		}
+	}
+}
+
+func TestSyslogHandlerUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	h := NewSyslogHandler("udp", conn.LocalAddr().String(),
+		WithLogLevel(DEBUG),
+		WithSyslogFacility(16),
+		WithSyslogAppName("app"),
+		WithSyslogMsgID("test"),
+	)
+	defer h.Close()
+
+	log := slog.New(h)
+
+	t.Run("Frame", func(t *testing.T) {
+		log.Log(context.Background(), CRITICAL, "test message", "a", 1)
+
+		frame := readPacket(t, conn)
+		pattern := `^<130>1 \d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\.\d{6}\S+ \S+ app ` + strconv.Itoa(os.Getpid()) +
+			` test \[slog@32473 a="1" source="gthb.fgfs.lggr.v3/syslog_test.go:\d+"\] test message$`
+		if !regexp.MustCompile(pattern).MatchString(frame) {
+			t.Errorf("unexpected frame %s", frame)
+		}
+	})
+
+	t.Run("StructuredData", func(t *testing.T) {
+		log.With("a", 1).WithGroup("g").Info("test", "s", `x"y]z\`, slog.Group("n", "b", true))
+
+		frame := readPacket(t, conn)
+		if !strings.Contains(frame, `[slog@32473 a="1" g.s="x\"y\]z\\" g.n.b="true" source=`) {
+			t.Errorf("unexpected frame %s", frame)
+		}
+	})
+
+	t.Run("NilStructuredData", func(t *testing.T) {
+		log := slog.New(NewSyslogHandler("udp", conn.LocalAddr().String(), WithoutSource()))
+		log.Info("test")
+
+		frame := readPacket(t, conn)
+		if !strings.HasSuffix(frame, " - - test") {
+			t.Errorf("unexpected frame %s", frame)
+		}
+	})
+}
+
+func TestSyslogHandlerTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	frames := make(chan string, 100)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			r := bufio.NewReader(conn)
+			for {
+				size, err := r.ReadString(' ')
+				if err != nil {
+					break
+				}
+				n, _ := strconv.Atoi(strings.TrimSpace(size))
+				frame := make([]byte, n)
+				if _, err := io.ReadFull(r, frame); err != nil {
+					break
+				}
+				frames <- string(frame)
+
+				// close connection, the client should reconnect
+				if strings.HasSuffix(string(frame), "close") {
+					break
+				}
+			}
+			conn.Close()
+		}
+	}()
+
+	h := NewSyslogHandler("tcp", ln.Addr().String())
+	defer h.Close()
+	log := slog.New(h)
+
+	log.Info("close")
+	if frame := <-frames; !strings.HasSuffix(frame, "] close") {
+		t.Errorf("unexpected frame %s", frame)
+	}
+
+	// the first write after connection is closed might be lost by TCP stack
+	for i := 0; i < 100; i++ {
+		log.Info("reconnect")
+
+		select {
+		case frame := <-frames:
+			if !strings.HasSuffix(frame, "] reconnect") {
+				t.Errorf("unexpected frame %s", frame)
+			}
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	t.Errorf("handler is not reconnected")
+}
+
+func readPacket(t *testing.T, conn net.PacketConn) string {
+	t.Helper()
+
+	buf := make([]byte, 64*1024)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(buf[:n])
+}
+
+func TestSyslogHandlerRedial(t *testing.T) {
+	path := t.TempDir() + "/syslog.sock"
+
+	h := NewSyslogHandler("unixgram", path)
+	defer h.Close()
+
+	if err := h.Handle(context.Background(), slog.NewRecord(time.Now(), INFO, "a", 0)); err == nil {
+		t.Fatal("syslog is not reachable")
+	}
+
+	conn, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// writes fail fast within the redial interval
+	if err := h.Handle(context.Background(), slog.NewRecord(time.Now(), INFO, "b", 0)); err == nil {
+		t.Errorf("syslog is re-dialed within the interval")
+	}
+
+	h.conn.Lock()
+	h.conn.redialAt = time.Time{}
+	h.conn.Unlock()
+
+	if err := h.Handle(context.Background(), slog.NewRecord(time.Now(), INFO, "c", 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	if frame := readPacket(t, conn); !strings.HasSuffix(frame, " c") {
+		t.Errorf("unexpected frame %s", frame)
+	}
+}
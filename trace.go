@@ -0,0 +1,40 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package logger
+
+import "context"
+
+// Trace is identity of the distributed trace the record belongs to.
+// Handlers correlate records with traces if the context carries it.
+type Trace struct {
+	TraceID string
+	SpanID  string
+	Sampled bool
+}
+
+type traceKey struct{}
+
+// ContextWithTrace attaches the trace to the context, records logged with
+// the context are correlated with the trace.
+//
+//	ctx = logger.ContextWithTrace(ctx, logger.Trace{TraceID: "...", SpanID: "..."})
+//	slog.InfoContext(ctx, "...")
+func ContextWithTrace(ctx context.Context, trace Trace) context.Context {
+	return context.WithValue(ctx, traceKey{}, trace)
+}
+
+// TraceFromContext returns the trace attached to the context
+func TraceFromContext(ctx context.Context) (Trace, bool) {
+	if ctx == nil {
+		return Trace{}, false
+	}
+
+	trace, ok := ctx.Value(traceKey{}).(Trace)
+	return trace, ok && trace.TraceID != ""
+}
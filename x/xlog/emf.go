@@ -0,0 +1,170 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package xlog
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Unit of CloudWatch metric
+type Unit string
+
+const (
+	UnitMilliseconds   Unit = "Milliseconds"
+	UnitCountPerSecond Unit = "Count/Second"
+	UnitCount          Unit = "Count"
+	UnitBytes          Unit = "Bytes"
+)
+
+// Metric is the value emitted as CloudWatch metric by EMF handler
+// (see [NewEMFHandler]), it is implemented by [Since], [PerSecond],
+// [MillisecondOp], [Count] and [Bytes].
+type Metric interface {
+	Metric() (float64, Unit)
+}
+
+// The metadata block of CloudWatch Embedded Metric Format
+type emfMetadata struct {
+	Timestamp         int64          `json:"Timestamp"`
+	CloudWatchMetrics []emfDirective `json:"CloudWatchMetrics"`
+}
+
+type emfDirective struct {
+	Namespace  string      `json:"Namespace"`
+	Dimensions [][]string  `json:"Dimensions"`
+	Metrics    []emfMetric `json:"Metrics"`
+}
+
+type emfMetric struct {
+	Name string `json:"Name"`
+	Unit Unit   `json:"Unit"`
+}
+
+// NewEMFHandler wraps JSON handler (logger.NewJSONHandler uses CloudWatch
+// preset by default) to emit records with metrics using CloudWatch Embedded Metric
+// Format. Top-level attributes implementing [Metric] are written as numbers
+// and declared within `_aws` metadata block, so that the record is both log
+// line and CloudWatch metric:
+//
+//	h := xlog.NewEMFHandler(logger.NewJSONHandler(), "MyApp", "service")
+//	slog.SetDefault(slog.New(h))
+//
+//	slog.Info("done", "service", "api", "duration", xlog.SinceNow())
+//
+//	{"msg":"done","service":"api","duration":12.3,"_aws":{"Timestamp":1735725600000,
+//	  "CloudWatchMetrics":[{"Namespace":"MyApp","Dimensions":[["service"]],
+//	  "Metrics":[{"Name":"duration","Unit":"Milliseconds"}]}]}}
+//
+// Dimensions are top-level string attributes of the record or attributes
+// defined via WithAttrs, missing dimensions are omitted. Records without
+// metrics are passed as-is. Metrics within groups are not supported by
+// the format, they are logged as-is.
+func NewEMFHandler(h slog.Handler, namespace string, dimensions ...string) slog.Handler {
+	return &emfHandler{
+		Handler:    h,
+		namespace:  namespace,
+		dimensions: dimensions,
+	}
+}
+
+type emfHandler struct {
+	slog.Handler
+	namespace  string
+	dimensions []string
+	attrs      []slog.Attr // top-level attributes defined via WithAttrs
+	grouped    bool
+}
+
+func (h *emfHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.Handler = h.Handler.WithAttrs(attrs)
+	if !h.grouped {
+		h2.attrs = append(h.attrs[:len(h.attrs):len(h.attrs)], attrs...)
+	}
+	return &h2
+}
+
+func (h *emfHandler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	h2.Handler = h.Handler.WithGroup(name)
+	h2.grouped = h.grouped || name != ""
+	return &h2
+}
+
+func (h *emfHandler) Handle(ctx context.Context, r slog.Record) error {
+	if h.grouped {
+		return h.Handler.Handle(ctx, r)
+	}
+
+	var metrics []emfMetric
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Value.Kind() != slog.KindAny {
+			return true
+		}
+		if _, ok := a.Value.Any().(Metric); ok {
+			metrics = append(metrics, emfMetric{Name: a.Key})
+		}
+		return true
+	})
+
+	if len(metrics) == 0 {
+		return h.Handler.Handle(ctx, r)
+	}
+
+	emf := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	dims := map[string]bool{}
+	seq := 0
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Value.Kind() != slog.KindAny {
+			if a.Value.Kind() == slog.KindString {
+				dims[a.Key] = true
+			}
+			emf.AddAttrs(a)
+			return true
+		}
+
+		if m, ok := a.Value.Any().(Metric); ok {
+			v, unit := m.Metric()
+			metrics[seq].Unit = unit
+			seq++
+			emf.AddAttrs(slog.Float64(a.Key, v))
+			return true
+		}
+
+		emf.AddAttrs(a)
+		return true
+	})
+
+	for _, a := range h.attrs {
+		if a.Value.Kind() == slog.KindString {
+			dims[a.Key] = true
+		}
+	}
+
+	dimensions := make([]string, 0, len(h.dimensions))
+	for _, dim := range h.dimensions {
+		if dims[dim] {
+			dimensions = append(dimensions, dim)
+		}
+	}
+
+	emf.AddAttrs(slog.Any("_aws", emfMetadata{
+		Timestamp: r.Time.UnixMilli(),
+		CloudWatchMetrics: []emfDirective{
+			{
+				Namespace:  h.namespace,
+				Dimensions: [][]string{dimensions},
+				Metrics:    metrics,
+			},
+		},
+	}))
+
+	return h.Handler.Handle(ctx, emf)
+}
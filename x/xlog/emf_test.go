@@ -0,0 +1,103 @@
+//
+// Copyright (C) 2021 - 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/logger
+//
+
+package xlog_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/fogfish/logger/x/xlog"
+)
+
+func TestEMFHandler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := slog.New(xlog.NewEMFHandler(slog.NewJSONHandler(buf, nil), "test", "service", "region"))
+
+	decode := func(t *testing.T) map[string]any {
+		t.Helper()
+
+		var val map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &val); err != nil {
+			t.Fatalf("invalid format, json expected %v", buf.String())
+		}
+		return val
+	}
+
+	t.Run("Metrics", func(t *testing.T) {
+		defer buf.Reset()
+
+		since := xlog.Since(time.Now().Add(-100 * time.Millisecond))
+		rate := xlog.PerSecondNow()
+		rate.Acc = 10
+
+		log.With("service", "api").Info("done",
+			"duration", since,
+			"rate", rate,
+			"items", xlog.Count(5),
+			"status", 200,
+		)
+		val := decode(t)
+
+		if d, ok := val["duration"].(float64); !ok || d < 100 || d > 1000 {
+			t.Errorf("unexpected duration %v", val["duration"])
+		}
+		if _, ok := val["rate"].(float64); !ok {
+			t.Errorf("unexpected rate %v", val["rate"])
+		}
+		if val["items"] != 5.0 || val["status"] != 200.0 {
+			t.Errorf("unexpected attributes %v", val)
+		}
+
+		aws, ok := val["_aws"].(map[string]any)
+		if !ok || aws["Timestamp"] == nil {
+			t.Fatalf("no _aws metadata found %v", val)
+		}
+
+		directive := aws["CloudWatchMetrics"].([]any)[0].(map[string]any)
+		if directive["Namespace"] != "test" {
+			t.Errorf("unexpected namespace %v", directive)
+		}
+
+		dims := directive["Dimensions"].([]any)[0].([]any)
+		if len(dims) != 1 || dims[0] != "service" {
+			t.Errorf("unexpected dimensions %v", dims)
+		}
+
+		units := map[string]string{}
+		for _, m := range directive["Metrics"].([]any) {
+			m := m.(map[string]any)
+			units[m["Name"].(string)] = m["Unit"].(string)
+		}
+		if units["duration"] != "Milliseconds" || units["rate"] != "Count/Second" ||
+			units["items"] != "Count" || len(units) != 3 {
+			t.Errorf("unexpected metrics %v", units)
+		}
+	})
+
+	t.Run("NoMetrics", func(t *testing.T) {
+		defer buf.Reset()
+
+		log.Info("done", "status", 200)
+		if val := decode(t); val["_aws"] != nil {
+			t.Errorf("unexpected _aws metadata %v", val)
+		}
+	})
+
+	t.Run("Group", func(t *testing.T) {
+		defer buf.Reset()
+
+		log.WithGroup("g").Info("done", "items", xlog.Count(5))
+		if val := decode(t); val["_aws"] != nil {
+			t.Errorf("unexpected _aws metadata %v", val)
+		}
+	})
+}
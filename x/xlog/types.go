@@ -19,6 +19,9 @@ type Since time.Time
 
 func (s Since) String() string               { return time.Since(time.Time(s)).String() }
 func (s Since) MarshalJSON() ([]byte, error) { return json.Marshal(s.String()) }
+func (s Since) Metric() (float64, Unit) {
+	return float64(time.Since(time.Time(s))) / float64(time.Millisecond), UnitMilliseconds
+}
 
 // SinceNow return instance of [Since] type initialized with [time.Now]
 func SinceNow() Since { return Since(time.Now()) }
@@ -37,6 +40,9 @@ func (r PerSecond) String() string {
 }
 
 func (r PerSecond) MarshalJSON() ([]byte, error) { return json.Marshal(r.String()) }
+func (r PerSecond) Metric() (float64, Unit) {
+	return float64(r.Acc) / time.Since(r.T).Seconds(), UnitCountPerSecond
+}
 
 // PerSecondNow return instance of [PerSecond] type initialized with [time.Now]
 func PerSecondNow() *PerSecond { return &PerSecond{T: time.Now(), Acc: 0} }
@@ -55,6 +61,24 @@ func (r MillisecondOp) String() string {
 }
 
 func (r MillisecondOp) MarshalJSON() ([]byte, error) { return json.Marshal(r.String()) }
+func (r MillisecondOp) Metric() (float64, Unit) {
+	if r.Acc == 0 {
+		return 0, UnitMilliseconds
+	}
+	return float64(time.Since(r.T)) / float64(time.Millisecond) / float64(r.Acc), UnitMilliseconds
+}
 
 // MillisecondOpNow return instance of [MillisecondOp] type initialized with [time.Now]
 func MillisecondOpNow() *MillisecondOp { return &MillisecondOp{T: time.Now(), Acc: 0} }
+
+//------------------------------------------------------------------------------
+
+// Count logs number of events
+type Count int
+
+func (c Count) Metric() (float64, Unit) { return float64(c), UnitCount }
+
+// Bytes logs size of data
+type Bytes int
+
+func (b Bytes) Metric() (float64, Unit) { return float64(b), UnitBytes }